@@ -4,6 +4,22 @@ import "github.com/spf13/viper"
 
 const DBDriverName = "postgres"
 
+// DefaultCyclesLimit is the number of cycles returned by cycles query without limit
+const DefaultCyclesLimit = 100
+
+// Stdin receiver modes
+const (
+	StdinModeInteractive = "interactive" // "> " prompt and pretty printed answer
+	StdinModeNDJSON      = "ndjson"      // request per line, compact answer per line
+)
+
+// Graph file formats
+const (
+	GraphFormatXML     = "xml"
+	GraphFormatGraphML = "graphml"
+	GraphFormatDOT     = "dot"
+)
+
 func init() {
 	viper.AutomaticEnv()
 
@@ -14,4 +30,27 @@ func init() {
 	viper.SetDefault("DB_PASSWORD", "graph_db_user")
 	viper.SetDefault("DB_SCHEMA", "graph")
 	viper.SetDefault("SSL_MODE", false)
+
+	// Graph file is loaded on start, format is detected by extension if not set
+	viper.SetDefault("GRAPH_FILE", "graph.xml")
+	viper.SetDefault("GRAPH_FORMAT", "")
+	viper.SetDefault("GRAPH_EXPORT_FILE", "")
+	viper.SetDefault("GRAPH_EXPORT_FORMAT", "")
+	// Accept negative edge costs, cheapest path searches use Bellman-Ford then
+	viper.SetDefault("GRAPH_NEGATIVE_COSTS", false)
+
+	// Queries evaluation, 0 workers means number of CPUs, 0 timeout means no deadline
+	viper.SetDefault("QUERY_WORKERS", 0)
+	viper.SetDefault("QUERY_TIMEOUT", "10s")
+	viper.SetDefault("REQUEST_TIMEOUT", "20s")
+
+	// Queries receivers. Empty HTTP_ADDR disables HTTP server, stdin is read without HTTP server only unless enabled
+	viper.SetDefault("HTTP_ADDR", "")
+	viper.SetDefault("STDIN_ENABLED", viper.GetString("HTTP_ADDR") == "")
+	viper.SetDefault("STDIN_MODE", StdinModeInteractive)
+	viper.SetDefault("STDIN_STREAM", false)
+	viper.SetDefault("HTTP_READ_TIMEOUT", "5s")
+	viper.SetDefault("HTTP_WRITE_TIMEOUT", "30s")
+	viper.SetDefault("HTTP_REQUEST_TIMEOUT", "25s")
+	viper.SetDefault("HTTP_SHUTDOWN_TIMEOUT", "10s")
 }
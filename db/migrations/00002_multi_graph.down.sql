@@ -0,0 +1,21 @@
+-- Node and edge IDs must be unique across graphs again, keep only the first graph
+DELETE FROM graphs WHERE id <> (SELECT min(id) FROM graphs);
+
+ALTER TABLE edges DROP CONSTRAINT IF EXISTS edges_previous_node_fkey;
+ALTER TABLE edges DROP CONSTRAINT IF EXISTS edges_next_node_fkey;
+ALTER TABLE edges DROP CONSTRAINT IF EXISTS edges_pkey;
+ALTER TABLE nodes DROP CONSTRAINT IF EXISTS nodes_graph_id_fkey;
+ALTER TABLE nodes DROP CONSTRAINT IF EXISTS nodes_pkey;
+
+DROP INDEX IF EXISTS idx_edges_previous_node;
+ALTER TABLE edges DROP COLUMN IF EXISTS graph_id;
+
+ALTER TABLE nodes ALTER COLUMN graph_id DROP NOT NULL;
+ALTER TABLE nodes ADD CONSTRAINT nodes_pkey PRIMARY KEY (id);
+ALTER TABLE nodes ADD CONSTRAINT nodes_graph_id_fkey FOREIGN KEY (graph_id) REFERENCES graphs;
+
+ALTER TABLE edges ADD CONSTRAINT edges_pkey PRIMARY KEY (id);
+ALTER TABLE edges ADD CONSTRAINT edges_previous_node_fkey FOREIGN KEY (previous_node) REFERENCES nodes;
+ALTER TABLE edges ADD CONSTRAINT edges_next_node_fkey FOREIGN KEY (next_node) REFERENCES nodes;
+
+CREATE index if not exists idx_edges_previous_node on edges(previous_node);
@@ -0,0 +1,32 @@
+-- Scope nodes and edges keys by graph, so many graphs can be stored side by side
+ALTER TABLE edges DROP CONSTRAINT IF EXISTS edges_previous_node_fkey;
+ALTER TABLE edges DROP CONSTRAINT IF EXISTS edges_next_node_fkey;
+ALTER TABLE edges DROP CONSTRAINT IF EXISTS edges_pkey;
+ALTER TABLE nodes DROP CONSTRAINT IF EXISTS nodes_graph_id_fkey;
+ALTER TABLE nodes DROP CONSTRAINT IF EXISTS nodes_pkey;
+
+ALTER TABLE edges ADD COLUMN IF NOT EXISTS graph_id VARCHAR(64);
+
+UPDATE edges e
+SET graph_id = n.graph_id
+FROM nodes n
+WHERE n.id = e.previous_node
+  AND e.graph_id IS NULL;
+
+DELETE FROM edges WHERE graph_id IS NULL;
+DELETE FROM nodes WHERE graph_id IS NULL;
+
+ALTER TABLE nodes ALTER COLUMN graph_id SET NOT NULL;
+ALTER TABLE nodes ADD CONSTRAINT nodes_pkey PRIMARY KEY (graph_id, id);
+ALTER TABLE nodes ADD CONSTRAINT nodes_graph_id_fkey FOREIGN KEY (graph_id) REFERENCES graphs ON DELETE CASCADE;
+
+ALTER TABLE edges ALTER COLUMN graph_id SET NOT NULL;
+ALTER TABLE edges ADD CONSTRAINT edges_pkey PRIMARY KEY (graph_id, id);
+ALTER TABLE edges ADD CONSTRAINT edges_previous_node_fkey FOREIGN KEY (graph_id, previous_node) REFERENCES nodes (graph_id, id) ON DELETE CASCADE;
+ALTER TABLE edges ADD CONSTRAINT edges_next_node_fkey FOREIGN KEY (graph_id, next_node) REFERENCES nodes (graph_id, id) ON DELETE CASCADE;
+
+DROP INDEX IF EXISTS idx_edges_previous_node;
+CREATE index if not exists idx_edges_previous_node on edges(graph_id, previous_node);
+
+comment on column nodes.graph_id is 'graph ID';
+comment on column edges.graph_id is 'graph ID';
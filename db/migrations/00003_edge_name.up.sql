@@ -0,0 +1,3 @@
+ALTER TABLE edges ADD COLUMN IF NOT EXISTS name VARCHAR(256) NOT NULL DEFAULT '';
+
+comment on column edges.name is 'edge name';
@@ -0,0 +1,3 @@
+ALTER TABLE edges ADD COLUMN IF NOT EXISTS bidirectional BOOLEAN NOT NULL DEFAULT FALSE;
+
+comment on column edges.bidirectional is 'edge can be traversed from next_node to previous_node as well';
@@ -0,0 +1,2 @@
+ALTER TABLE nodes DROP COLUMN IF EXISTS attributes;
+ALTER TABLE edges DROP COLUMN IF EXISTS attributes;
@@ -0,0 +1,5 @@
+ALTER TABLE nodes ADD COLUMN IF NOT EXISTS attributes JSONB NOT NULL DEFAULT '{}';
+ALTER TABLE edges ADD COLUMN IF NOT EXISTS attributes JSONB NOT NULL DEFAULT '{}';
+
+comment on column nodes.attributes is 'node attributes, string, number or bool values by name';
+comment on column edges.attributes is 'edge attributes, string, number or bool values by name';
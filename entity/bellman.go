@@ -0,0 +1,173 @@
+package entity
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// NegativeCycleError is returned by searches over a graph with negative costs
+// when a cycle of negative total cost makes the cheapest path unbounded.
+type NegativeCycleError struct {
+	Cycle Cycle
+}
+
+func (e *NegativeCycleError) Error() string {
+	return fmt.Sprintf("negative cost cycle %s", strings.Join(e.Cycle.Nodes, " -> "))
+}
+
+// bellmanFord is Bellman-Ford search from origin over adjacency for graphs with negative costs,
+// it skips nodes and edges not allowed by filter, removedNodes and removedEdges.
+// It returns the least cost of every reached node and the edge it is reached by,
+// and the nodes whose cost is unbounded because a negative cycle leads to them.
+func (g Graph) bellmanFord(c *canceller, adjacency map[string][]Edge, origin string, filter Filter,
+	removedNodes map[string]bool, removedEdges map[edgeKey]bool) (map[string]float64, map[string]step, map[string]bool, *Cycle, error) {
+	var (
+		dist     = make(map[string]float64)
+		previous = make(map[string]step)
+		nodes    = g.sortedNodes()
+		relaxed  []string // nodes relaxed in the last round
+	)
+
+	if _, ok := adjacency[origin]; !ok || removedNodes[origin] || !filter.allowNode(g, origin) {
+		return dist, previous, nil, nil, nil
+	}
+	dist[origin] = 0
+
+	allowed := func(from string, e Edge) bool {
+		return !removedNodes[e.Next] && !removedEdges[edgeKey{from: from, to: e.Next, id: e.ID}] &&
+			filter.allowEdge(e) && filter.allowNode(g, e.Next)
+	}
+
+	// every cheapest path has less than len(nodes) edges, a relaxation in round len(nodes) means negative cycle
+	for round := 0; round < len(nodes); round++ {
+		relaxed = relaxed[:0]
+
+		for _, n := range nodes {
+			if err := c.err(); err != nil {
+				return nil, nil, nil, nil, err
+			}
+
+			d, ok := dist[n]
+			if !ok {
+				continue
+			}
+
+			for _, e := range adjacency[n] {
+				if !allowed(n, e) {
+					continue
+				}
+
+				if old, ok := dist[e.Next]; !ok || d+e.Cost < old {
+					dist[e.Next] = d + e.Cost
+					previous[e.Next] = step{node: n, edge: e.ID}
+					relaxed = append(relaxed, e.Next)
+				}
+			}
+		}
+
+		if len(relaxed) == 0 {
+			return dist, previous, nil, nil, nil
+		}
+	}
+
+	// every negative cycle still has a relaxed node, so all nodes reached from relaxed ones have unbounded cost
+	unbounded := make(map[string]bool)
+	for _, n := range relaxed {
+		if unbounded[n] {
+			continue
+		}
+
+		for m := range bfs(n, func(n string, visit func(string)) {
+			for _, e := range adjacency[n] {
+				if allowed(n, e) {
+					visit(e.Next)
+				}
+			}
+		}) {
+			unbounded[m] = true
+		}
+	}
+
+	// predecessors of relaxed node lead to a negative cycle, walk back len(nodes) times to be surely on it
+	last := relaxed[0]
+	for i := 0; i < len(nodes); i++ {
+		last = previous[last].node
+	}
+
+	cycle := Cycle{Nodes: []string{last}}
+	for n := last; ; {
+		s := previous[n]
+		cycle.Nodes = append(cycle.Nodes, s.node)
+		cycle.Edges = append(cycle.Edges, s.edge)
+
+		if n = s.node; n == last || len(cycle.Edges) > len(nodes) {
+			break
+		}
+	}
+	slices.Reverse(cycle.Nodes)
+	slices.Reverse(cycle.Edges)
+
+	return dist, previous, unbounded, &cycle, nil
+}
+
+// shortestPathNegative is the cheapest path search for graphs with negative costs,
+// it fails with NegativeCycleError when a negative cycle leads to end.
+func (g Graph) shortestPathNegative(c *canceller, filter Filter, start, end string,
+	removedNodes map[string]bool, removedEdges map[edgeKey]bool) (*PathsCost, error) {
+	dist, previous, unbounded, cycle, err := g.bellmanFord(c, g.AdjacencyList, start, filter, removedNodes, removedEdges)
+	if err != nil {
+		return nil, err
+	}
+
+	if unbounded[end] {
+		return nil, &NegativeCycleError{Cycle: *cycle}
+	}
+
+	cost, ok := dist[end]
+	if !ok {
+		return nil, nil
+	}
+
+	path := buildPath(previous, start, end)
+	path.TotalCost = cost
+
+	return &path, nil
+}
+
+// shortestTreeNegative is shortestTree for graphs with negative costs, nodes over maxCost are dropped after search
+func (g Graph) shortestTreeNegative(c *canceller, adjacency map[string][]Edge, origin string, maxCost float64,
+	filter Filter) (map[string]float64, map[string]step, error) {
+	dist, previous, _, cycle, err := g.bellmanFord(c, adjacency, origin, filter, nil, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if cycle != nil {
+		return nil, nil, &NegativeCycleError{Cycle: *cycle}
+	}
+
+	if maxCost > 0 {
+		for n, d := range dist {
+			if d > maxCost {
+				delete(dist, n)
+				delete(previous, n)
+			}
+		}
+	}
+
+	return dist, previous, nil
+}
+
+// hasNegativeCost reports some edge of the graph has negative cost
+func hasNegativeCost(adjacency map[string][]Edge) bool {
+	for _, edges := range adjacency {
+		for _, e := range edges {
+			if e.Cost < 0 {
+				return true
+			}
+		}
+	}
+
+	return false
+}
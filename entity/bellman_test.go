@@ -0,0 +1,226 @@
+package entity
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+// randomNegativeGraph builds a graph of nodes "0".."nodes-1" with negative costs on forward edges.
+// Backward edges are dear enough to leave no negative cycle unless negativeCycles is set.
+func randomNegativeGraph(rnd *rand.Rand, nodes, edges int, negativeCycles bool) *Graph {
+	graph := Graph{AdjacencyList: make(map[string][]Edge, nodes)}
+	for i := 0; i < nodes; i++ {
+		graph.AdjacencyList[strconv.Itoa(i)] = nil
+	}
+
+	for i := 0; i < edges; i++ {
+		from, to := rnd.Intn(nodes), rnd.Intn(nodes)
+		if from == to {
+			continue
+		}
+
+		cost := float64(rnd.Intn(10) - 5)
+		if from > to && !negativeCycles {
+			cost += 50
+		}
+
+		graph.AdjacencyList[strconv.Itoa(from)] = append(graph.AdjacencyList[strconv.Itoa(from)],
+			Edge{ID: "e" + strconv.Itoa(i), Next: strconv.Itoa(to), Cost: cost})
+	}
+	graph.NegativeCosts = hasNegativeCost(graph.AdjacencyList)
+
+	return &graph
+}
+
+func TestBellmanFordMatchesSimplePaths(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	for run := 0; run < 200; run++ {
+		graph := randomNegativeGraph(rnd, 7, 16, false)
+
+		tree, err := graph.ShortestPathTree(context.Background(), "0", Filter{})
+		if err != nil {
+			t.Fatalf("run %d: unexpected error %v", run, err)
+		}
+
+		for end := 1; end < 7; end++ {
+			var (
+				to    = strconv.Itoa(end)
+				all   = simplePaths(graph, "0", to)
+				cost  = math.Inf(1)
+				found = len(all) > 0
+			)
+			if found {
+				cost = all[0].TotalCost
+			}
+
+			path, err := graph.GetCheapestPaths(context.Background(), "0", to, Filter{})
+			if err != nil {
+				t.Fatalf("run %d: unexpected error %v", run, err)
+			}
+
+			if (path != nil) != found {
+				t.Fatalf("run %d: expected path to %s %t, got %+v", run, to, found, path)
+			}
+
+			if !found {
+				continue
+			}
+
+			if path.TotalCost != cost || graph.pathCost(path.Path, path.Edges) != cost || tree.Costs[to] != cost {
+				t.Errorf("run %d: expected cost %v to %s, got path %+v and tree cost %v", run, cost, to, path, tree.Costs[to])
+			}
+		}
+	}
+}
+
+func TestNegativeCycleError(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	cycles := 0
+
+	for run := 0; run < 200; run++ {
+		graph := randomNegativeGraph(rnd, 6, 14, true)
+
+		for start := range graph.AdjacencyList {
+			_, err := graph.ShortestPathTree(context.Background(), start, Filter{})
+
+			var nc *NegativeCycleError
+			if !errors.As(err, &nc) {
+				if err != nil {
+					t.Fatalf("run %d: unexpected error %v", run, err)
+				}
+				continue
+			}
+			cycles++
+
+			c := nc.Cycle
+			if c.Nodes[0] != c.Nodes[len(c.Nodes)-1] || len(c.Edges) != len(c.Nodes)-1 {
+				t.Fatalf("run %d: cycle %v is not closed", run, c)
+			}
+
+			cost := 0.0
+			for i, id := range c.Edges {
+				e, ok := graph.Edge(c.Nodes[i], id)
+				if !ok || e.Next != c.Nodes[i+1] {
+					t.Fatalf("run %d: cycle %v has no edge %s from %s", run, c, id, c.Nodes[i])
+				}
+				cost += e.Cost
+			}
+
+			if cost >= 0 {
+				t.Errorf("run %d: expected negative cost of cycle %v, got %v", run, c, cost)
+			}
+		}
+	}
+
+	if cycles == 0 {
+		t.Error("expected some graphs with negative cycles")
+	}
+}
+
+func TestNegativeCycleUnreachedFromPath(t *testing.T) {
+	graph := &Graph{AdjacencyList: map[string][]Edge{
+		"a": {{ID: "a1", Next: "b", Cost: 4}, {ID: "a2", Next: "c", Cost: 3}},
+		"b": {{ID: "b1", Next: "c", Cost: -2}},
+		"c": {{ID: "c1", Next: "d", Cost: 1}},
+		"d": {{ID: "d1", Next: "c", Cost: -3}},
+	}}
+	graph.NegativeCosts = hasNegativeCost(graph.AdjacencyList)
+
+	// b is not reached from the cycle, its cheapest path is bounded
+	if path, err := graph.GetCheapestPaths(context.Background(), "a", "b", Filter{}); err != nil || path == nil || path.TotalCost != 4 {
+		t.Errorf("expected path to b of cost 4, got %+v, %v", path, err)
+	}
+
+	_, err := graph.GetCheapestPaths(context.Background(), "a", "d", Filter{})
+
+	var nc *NegativeCycleError
+	if !errors.As(err, &nc) || len(nc.Cycle.Edges) != 2 {
+		t.Errorf("expected negative cycle over c and d, got %v", err)
+	}
+}
+
+func TestNegativeCycleOnSideBranch(t *testing.T) {
+	// negative cycle x y is reached from a but leads nowhere near e
+	graph := &Graph{AdjacencyList: map[string][]Edge{
+		"a": {{ID: "a1", Next: "x", Cost: 1}, {ID: "a2", Next: "b", Cost: 2}},
+		"x": {{ID: "x1", Next: "y", Cost: -3}},
+		"y": {{ID: "y1", Next: "x", Cost: 1}},
+		"b": {{ID: "b1", Next: "e", Cost: 3}},
+		"e": nil,
+	}}
+	graph.NegativeCosts = hasNegativeCost(graph.AdjacencyList)
+
+	if path, err := graph.GetCheapestPaths(context.Background(), "a", "e", Filter{}); err != nil || path == nil || path.TotalCost != 5 {
+		t.Errorf("expected path to e of cost 5, got %+v, %v", path, err)
+	}
+
+	_, err := graph.GetCheapestPaths(context.Background(), "a", "y", Filter{})
+
+	var nc *NegativeCycleError
+	if !errors.As(err, &nc) || len(nc.Cycle.Edges) != 2 {
+		t.Errorf("expected negative cycle over x and y, got %v", err)
+	}
+}
+
+func TestNegativeCycleOnlyWhenItLeadsToEnd(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	bounded, unbounded := 0, 0
+
+	for run := 0; run < 200; run++ {
+		graph := randomNegativeGraph(rnd, 6, 10, true)
+
+		cycles, err := graph.FindCycles(context.Background(), 0)
+		if err != nil {
+			t.Fatalf("run %d: unexpected error %v", run, err)
+		}
+
+		// nodes of negative cycles found by brute force
+		onNegativeCycle := make(map[string]bool)
+		for _, c := range cycles {
+			if graph.pathCost(c.Nodes, c.Edges) < 0 {
+				for _, n := range c.Nodes {
+					onNegativeCycle[n] = true
+				}
+			}
+		}
+
+		for start := range graph.AdjacencyList {
+			fromStart := reachableFrom(graph, start)
+
+			for end := range graph.AdjacencyList {
+				expected := false
+				for n := range onNegativeCycle {
+					if fromStart[n] && reachableFrom(graph, n)[end] {
+						expected = true
+					}
+				}
+
+				path, err := graph.GetCheapestPaths(context.Background(), start, end, Filter{})
+
+				var nc *NegativeCycleError
+				if got := errors.As(err, &nc); got != expected {
+					t.Fatalf("run %d: %s -> %s: expected negative cycle %t, got %+v, %v", run, start, end, expected, path, err)
+				}
+
+				if expected {
+					unbounded++
+					continue
+				}
+				bounded++
+
+				if all := simplePaths(graph, start, end); err != nil || (path == nil) != (len(all) == 0) || (path != nil && path.TotalCost != all[0].TotalCost) {
+					t.Errorf("run %d: %s -> %s: expected cheapest of %v, got %+v, %v", run, start, end, all, path, err)
+				}
+			}
+		}
+	}
+
+	if bounded == 0 || unbounded == 0 {
+		t.Errorf("expected both bounded and unbounded paths, got %d and %d", bounded, unbounded)
+	}
+}
@@ -0,0 +1,27 @@
+package entity
+
+import "context"
+
+// cancelCheckInterval is number of search steps between context checks,
+// ctx.Err takes a lock so it is not called on every step.
+const cancelCheckInterval = 1024
+
+// canceller stops long searches when context is done.
+type canceller struct {
+	ctx   context.Context
+	steps int
+}
+
+func newCanceller(ctx context.Context) *canceller {
+	return &canceller{ctx: ctx}
+}
+
+// err counts a search step and returns context error once in cancelCheckInterval steps.
+func (c *canceller) err() error {
+	c.steps++
+	if c.steps%cancelCheckInterval != 0 {
+		return nil
+	}
+
+	return c.ctx.Err()
+}
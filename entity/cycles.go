@@ -0,0 +1,202 @@
+package entity
+
+import (
+	"context"
+)
+
+// Cycle is an elementary cycle, Nodes starts and ends with the same node,
+// Edges[i] is the edge ID from Nodes[i] to Nodes[i+1].
+type Cycle struct {
+	Nodes []string
+	Edges []string
+}
+
+// FindCycles returns up to limit elementary cycles, limit <= 0 means all of them.
+// Search is stopped with ctx error when ctx is done.
+// Johnson's algorithm: for every start node in ID order, cycles are searched
+// only in its strongly connected component among nodes not used as start yet,
+// nodes which can't lead back to start stay blocked to avoid repeated search.
+// Parallel edges give separate cycles.
+func (g Graph) FindCycles(ctx context.Context, limit int) ([]Cycle, error) {
+	var (
+		nodes   = g.sortedNodes()
+		index   = make(map[string]int, len(g.AdjacencyList))
+		reverse = make(map[string][]string, len(g.AdjacencyList))
+		res     = make([]Cycle, 0)
+	)
+
+	for i, n := range nodes {
+		index[n] = i
+	}
+
+	for n, edges := range g.AdjacencyList {
+		for _, e := range edges {
+			reverse[e.Next] = append(reverse[e.Next], n)
+		}
+	}
+
+	c := newCanceller(ctx)
+
+	for i, start := range nodes {
+		f := cycleFinder{
+			canceller: c,
+			graph:     g,
+			start:     start,
+			component: g.startComponent(start, i, index, reverse),
+			blocked:   make(map[string]bool),
+			blockedBy: make(map[string]map[string]bool),
+			limit:     limit,
+			cycles:    res,
+		}
+
+		f.circuit(start)
+		res = f.cycles
+
+		if f.err != nil {
+			return nil, f.err
+		}
+
+		if limit > 0 && len(res) >= limit {
+			break
+		}
+	}
+
+	return res, nil
+}
+
+// startComponent returns nodes with index >= from which are reachable from start and lead back to it.
+func (g Graph) startComponent(start string, from int, index map[string]int, reverse map[string][]string) map[string]bool {
+	forward := bfs(start, func(n string, visit func(string)) {
+		for _, e := range g.AdjacencyList[n] {
+			if i, ok := index[e.Next]; ok && i >= from {
+				visit(e.Next)
+			}
+		}
+	})
+
+	backward := bfs(start, func(n string, visit func(string)) {
+		for _, prev := range reverse[n] {
+			if index[prev] >= from {
+				visit(prev)
+			}
+		}
+	})
+
+	for n := range forward {
+		if !backward[n] {
+			delete(forward, n)
+		}
+	}
+
+	return forward
+}
+
+// bfs returns all nodes visited from start, next calls visit for every neighbour of the node.
+func bfs(start string, next func(n string, visit func(string))) map[string]bool {
+	var (
+		visited = map[string]bool{start: true}
+		queue   = []string{start}
+	)
+
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+
+		next(n, func(m string) {
+			if !visited[m] {
+				visited[m] = true
+				queue = append(queue, m)
+			}
+		})
+	}
+
+	return visited
+}
+
+type cycleFinder struct {
+	canceller *canceller
+	graph     Graph
+	start     string
+	component map[string]bool
+	blocked   map[string]bool
+	blockedBy map[string]map[string]bool
+	nodeStack []string
+	edgeStack []string
+	limit     int
+	cycles    []Cycle
+	done      bool
+	err       error
+}
+
+// circuit extends current path with v and reports whether any cycle was closed through it.
+func (f *cycleFinder) circuit(v string) bool {
+	found := false
+
+	if f.err = f.canceller.err(); f.err != nil {
+		f.done = true
+		return false
+	}
+
+	f.nodeStack = append(f.nodeStack, v)
+	f.blocked[v] = true
+
+	for _, e := range f.graph.AdjacencyList[v] {
+		if f.done {
+			break
+		}
+
+		if !f.component[e.Next] {
+			continue
+		}
+
+		f.edgeStack = append(f.edgeStack, e.ID)
+
+		if e.Next == f.start {
+			// going there and back over the same bidirectional edge is not a cycle,
+			// other cycles have distinct nodes and so can't repeat an edge
+			if len(f.edgeStack) != 2 || f.edgeStack[0] != f.edgeStack[1] {
+				f.cycles = append(f.cycles, Cycle{
+					Nodes: append(append(make([]string, 0, len(f.nodeStack)+1), f.nodeStack...), f.start),
+					Edges: append(make([]string, 0, len(f.edgeStack)), f.edgeStack...),
+				})
+				f.done = f.limit > 0 && len(f.cycles) >= f.limit
+			}
+			found = true
+		} else if !f.blocked[e.Next] && f.circuit(e.Next) {
+			found = true
+		}
+
+		f.edgeStack = f.edgeStack[:len(f.edgeStack)-1]
+	}
+
+	if found {
+		f.unblock(v)
+	} else {
+		// v stays blocked until one of its successors is unblocked
+		for _, e := range f.graph.AdjacencyList[v] {
+			if !f.component[e.Next] {
+				continue
+			}
+
+			if f.blockedBy[e.Next] == nil {
+				f.blockedBy[e.Next] = make(map[string]bool)
+			}
+			f.blockedBy[e.Next][v] = true
+		}
+	}
+
+	f.nodeStack = f.nodeStack[:len(f.nodeStack)-1]
+
+	return found
+}
+
+func (f *cycleFinder) unblock(v string) {
+	f.blocked[v] = false
+
+	for w := range f.blockedBy[v] {
+		delete(f.blockedBy[v], w)
+		if f.blocked[w] {
+			f.unblock(w)
+		}
+	}
+}
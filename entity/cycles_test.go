@@ -0,0 +1,107 @@
+package entity
+
+import (
+	"context"
+	"graphs/entity/postgre"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// countCycles counts elementary cycles by brute force, every cycle is counted from its least node
+func countCycles(g *Graph) int {
+	var (
+		count   int
+		visited = make(map[string]bool)
+		walk    func(start, n string, edges []string)
+	)
+
+	walk = func(start, n string, edges []string) {
+		for _, e := range g.AdjacencyList[n] {
+			switch {
+			case e.Next == start:
+				// there and back over one bidirectional edge is not a cycle
+				if len(edges) != 1 || edges[0] != e.ID {
+					count++
+				}
+			case e.Next > start && !visited[e.Next]:
+				visited[e.Next] = true
+				walk(start, e.Next, append(edges, e.ID))
+				visited[e.Next] = false
+			}
+		}
+	}
+
+	for n := range g.AdjacencyList {
+		walk(n, n, nil)
+	}
+
+	return count
+}
+
+func TestFindCyclesMatchesBruteForce(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	for run := 0; run < 200; run++ {
+		graph := randomGraph(rnd, 6, 14)
+
+		cycles, err := graph.FindCycles(context.Background(), 0)
+		if err != nil {
+			t.Fatalf("run %d: unexpected error %v", run, err)
+		}
+
+		if expected := countCycles(graph); len(cycles) != expected {
+			t.Fatalf("run %d: expected %d cycles, got %d", run, expected, len(cycles))
+		}
+
+		for i, c := range cycles {
+			if c.Nodes[0] != c.Nodes[len(c.Nodes)-1] || len(c.Edges) != len(c.Nodes)-1 {
+				t.Errorf("run %d: cycle %v is not closed", run, c)
+			}
+
+			for j := 0; j < len(c.Edges); j++ {
+				if e, ok := graph.Edge(c.Nodes[j], c.Edges[j]); !ok || e.Next != c.Nodes[j+1] {
+					t.Errorf("run %d: cycle %v has no edge %s from %s", run, c, c.Edges[j], c.Nodes[j])
+				}
+			}
+
+			for _, other := range cycles[:i] {
+				if slices.Equal(c.Nodes, other.Nodes) && slices.Equal(c.Edges, other.Edges) {
+					t.Errorf("run %d: cycle %v is returned twice", run, c)
+				}
+			}
+		}
+
+		if limited, err := graph.FindCycles(context.Background(), 2); err != nil || len(limited) != min(2, len(cycles)) {
+			t.Errorf("run %d: expected %d cycles by limit, got %d, %v", run, min(2, len(cycles)), len(limited), err)
+		}
+	}
+}
+
+func TestFindCyclesParallelAndBidirectionalEdges(t *testing.T) {
+	graph := NewGraph(postgre.Graph{
+		Nodes: []postgre.Node{{ID: "a"}, {ID: "b"}},
+		Edges: []postgre.Edge{
+			{ID: "a1", PreviousNode: "a", NextNode: "b", Cost: 1},
+			{ID: "a2", PreviousNode: "a", NextNode: "b", Cost: 1},
+			{ID: "ab", PreviousNode: "a", NextNode: "b", Cost: 1, Bidirectional: true},
+			{ID: "b1", PreviousNode: "b", NextNode: "a", Cost: 1},
+		},
+	})
+
+	cycles, err := graph.FindCycles(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	// a1, a2 and ab forward by ab backward and b1, except there and back over ab
+	if len(cycles) != 5 {
+		t.Errorf("expected 5 cycles, got %d: %v", len(cycles), cycles)
+	}
+
+	for _, c := range cycles {
+		if c.Edges[0] == "ab" && c.Edges[1] == "ab" {
+			t.Errorf("expected no cycle there and back over ab, got %v", c)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package dot
+
+import (
+	"bufio"
+	"fmt"
+	"graphs/entity"
+	"graphs/entity/postgre"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const highlightAttrs = `color="red", fontcolor="red", penwidth=2`
+
+// Highlight marks part of the graph rendered in red.
+type Highlight struct {
+	// Path is a node sequence, e.g. path found by Graph.GetCheapestPaths.
+	Path []string
+	// Edges are edge IDs, e.g. cycle returned by Graph.FindCycles. If Path is set, Edges[i] is the edge
+	// from Path[i] to Path[i+1] and only the direction taken of bidirectional edge is highlighted.
+	Edges []string
+}
+
+// highlightedEdges are edges of Highlight, edges along the path are keyed by direction too
+type highlightedEdges struct {
+	ids   map[string]bool
+	steps map[[3]string]bool // from, to, edge ID
+}
+
+// Encode writes stored graph in Graphviz DOT format, nodes are labeled with names and edges with costs.
+func Encode(w io.Writer, graph postgre.Graph, h Highlight) error {
+	var (
+		pathNodes = h.pathNodes()
+		edges     = h.edges()
+	)
+
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "digraph %s {\n", quote(graph.ID))
+	if graph.Name != "" {
+		fmt.Fprintf(bw, "  label=%s;\n", quote(graph.Name))
+	}
+
+	for _, n := range graph.Nodes {
+		writeNode(bw, n.ID, nodeLabel(n.ID, n.Name), pathNodes[n.ID])
+	}
+
+	for _, e := range graph.Edges {
+		writeEdge(bw, e.PreviousNode, e.NextNode, edgeLabel(e.ID, e.Name, e.Cost), e.Bidirectional,
+			edges.has(e.PreviousNode, e.NextNode, e.ID, e.Bidirectional))
+	}
+
+	fmt.Fprintln(bw, "}")
+
+	return bw.Flush()
+}
+
+// EncodeGraph writes in-memory graph in Graphviz DOT format. Nodes and edges are sorted for stable output.
+// Bidirectional edge is written as two edges, one per direction.
+func EncodeGraph(w io.Writer, graph *entity.Graph, h Highlight) error {
+	var (
+		pathNodes = h.pathNodes()
+		edges     = h.edges()
+		nodes     = make([]string, 0, len(graph.AdjacencyList))
+	)
+
+	for n := range graph.AdjacencyList {
+		nodes = append(nodes, n)
+	}
+	sort.Strings(nodes)
+
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "digraph %s {\n", quote(graph.ID))
+	if graph.Name != "" {
+		fmt.Fprintf(bw, "  label=%s;\n", quote(graph.Name))
+	}
+
+	for _, n := range nodes {
+		writeNode(bw, n, nodeLabel(n, graph.Nodes[n].Name), pathNodes[n])
+	}
+
+	for _, n := range nodes {
+		for _, e := range graph.AdjacencyList[n] {
+			writeEdge(bw, n, e.Next, edgeLabel(e.ID, e.Name, e.Cost), false, edges.has(n, e.Next, e.ID, false))
+		}
+	}
+
+	fmt.Fprintln(bw, "}")
+
+	return bw.Flush()
+}
+
+func (h Highlight) pathNodes() map[string]bool {
+	nodes := make(map[string]bool, len(h.Path))
+	for _, n := range h.Path {
+		nodes[n] = true
+	}
+
+	return nodes
+}
+
+func (h Highlight) edges() highlightedEdges {
+	edges := highlightedEdges{ids: make(map[string]bool), steps: make(map[[3]string]bool)}
+
+	if len(h.Path) == len(h.Edges)+1 {
+		for i, id := range h.Edges {
+			edges.steps[[3]string{h.Path[i], h.Path[i+1], id}] = true
+		}
+
+		return edges
+	}
+
+	for _, id := range h.Edges {
+		edges.ids[id] = true
+	}
+
+	return edges
+}
+
+// has reports edge id from -> to is highlighted, bidirectional edge is highlighted in either direction
+func (e highlightedEdges) has(from, to, id string, bidirectional bool) bool {
+	return e.ids[id] || e.steps[[3]string{from, to, id}] || (bidirectional && e.steps[[3]string{to, from, id}])
+}
+
+func writeNode(w io.Writer, id, label string, highlight bool) {
+	attrs := "label=" + quote(label)
+	if highlight {
+		attrs += ", " + highlightAttrs
+	}
+
+	fmt.Fprintf(w, "  %s [%s];\n", quote(id), attrs)
+}
+
+func writeEdge(w io.Writer, from, to, label string, bidirectional, highlight bool) {
+	attrs := "label=" + quote(label)
+	if bidirectional {
+		attrs += ", dir=both"
+	}
+	if highlight {
+		attrs += ", " + highlightAttrs
+	}
+
+	fmt.Fprintf(w, "  %s -> %s [%s];\n", quote(from), quote(to), attrs)
+}
+
+// nodeLabel is "id\nname", name is omitted if empty
+func nodeLabel(id, name string) string {
+	if name == "" {
+		return id
+	}
+
+	return id + "\n" + name
+}
+
+// edgeLabel is "id name: cost", empty parts are omitted
+func edgeLabel(id, name string, cost float64) string {
+	label := strings.TrimSpace(id + " " + name)
+	if label == "" {
+		return formatCost(cost)
+	}
+
+	return label + ": " + formatCost(cost)
+}
+
+func formatCost(cost float64) string {
+	return strconv.FormatFloat(cost, 'f', -1, 64)
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// quote makes DOT double-quoted string
+func quote(s string) string {
+	return `"` + escaper.Replace(s) + `"`
+}
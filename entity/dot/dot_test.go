@@ -0,0 +1,70 @@
+package dot
+
+import (
+	"bytes"
+	"graphs/entity"
+	"graphs/entity/postgre"
+	"strings"
+	"testing"
+)
+
+func testGraph() postgre.Graph {
+	return postgre.Graph{
+		ID:    "g0",
+		Nodes: []postgre.Node{{ID: "a", Name: "A name"}, {ID: "b"}, {ID: "c"}},
+		Edges: []postgre.Edge{
+			{ID: "a1", PreviousNode: "a", NextNode: "b", Cost: 1},
+			{ID: "a2", PreviousNode: "a", NextNode: "b", Cost: 5},
+			{ID: "c1", PreviousNode: "b", NextNode: "c", Cost: 1, Bidirectional: true},
+		},
+	}
+}
+
+func TestEncodeGraphHighlightsPathEdges(t *testing.T) {
+	var (
+		buf   bytes.Buffer
+		graph = entity.NewGraph(testGraph())
+	)
+
+	// path takes the dearer parallel edge and the bidirectional edge backwards
+	h := Highlight{Path: []string{"a", "b"}, Edges: []string{"a2"}}
+	if err := EncodeGraph(&buf, graph, h); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	out := buf.String()
+	for _, line := range []string{
+		`"a" [label="a\nA name", color="red"`,
+		`"b" [label="b", color="red"`,
+		`"a" -> "b" [label="a1: 1"];`,
+		`"a" -> "b" [label="a2: 5", color="red"`,
+	} {
+		if !strings.Contains(out, line) {
+			t.Errorf("expected %s in\n%s", line, out)
+		}
+	}
+
+	buf.Reset()
+	h = Highlight{Path: []string{"c", "b"}, Edges: []string{"c1"}}
+	if err := EncodeGraph(&buf, graph, h); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	out = buf.String()
+	if !strings.Contains(out, `"c" -> "b" [label="c1: 1", color="red"`) || !strings.Contains(out, `"b" -> "c" [label="c1: 1"];`) {
+		t.Errorf("expected only c -> b direction highlighted in\n%s", out)
+	}
+}
+
+func TestEncodeHighlightsReversedBidirectionalEdge(t *testing.T) {
+	var buf bytes.Buffer
+
+	h := Highlight{Path: []string{"c", "b"}, Edges: []string{"c1"}}
+	if err := Encode(&buf, testGraph(), h); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if line := `"b" -> "c" [label="c1: 1", dir=both, color="red"`; !strings.Contains(buf.String(), line) {
+		t.Errorf("expected %s in\n%s", line, buf.String())
+	}
+}
@@ -0,0 +1,122 @@
+package entity
+
+import "slices"
+
+// Predicate operators, attribute is compared with the predicate value
+const (
+	OpEq     = "eq" // default
+	OpNe     = "ne"
+	OpLt     = "lt" // numbers only, as lte, gt and gte
+	OpLte    = "lte"
+	OpGt     = "gt"
+	OpGte    = "gte"
+	OpExists = "exists" // attribute is set, value is not used
+)
+
+type (
+	// Predicate matches node or edge with ID in IDs and attribute compared with Value by Op.
+	// Empty IDs or Attribute are not checked.
+	Predicate struct {
+		IDs       []string
+		Attribute string
+		Op        string
+		Value     interface{}
+	}
+
+	// Filter restricts nodes and edges a search may go through, zero Filter allows everything.
+	// Node or edge must match all include predicates and none of exclude predicates.
+	Filter struct {
+		IncludeNodes []Predicate
+		ExcludeNodes []Predicate
+		IncludeEdges []Predicate
+		ExcludeEdges []Predicate
+	}
+)
+
+// ValidOp reports op is a known predicate operator, empty op is OpEq
+func ValidOp(op string) bool {
+	switch op {
+	case "", OpEq, OpNe, OpLt, OpLte, OpGt, OpGte, OpExists:
+		return true
+	}
+
+	return false
+}
+
+// allowNode reports search may go through node id
+func (f Filter) allowNode(g Graph, id string) bool {
+	if len(f.IncludeNodes) == 0 && len(f.ExcludeNodes) == 0 {
+		return true
+	}
+
+	return allow(f.IncludeNodes, f.ExcludeNodes, id, g.Nodes[id].Attributes)
+}
+
+// allowEdge reports search may go through edge
+func (f Filter) allowEdge(e Edge) bool {
+	if len(f.IncludeEdges) == 0 && len(f.ExcludeEdges) == 0 {
+		return true
+	}
+
+	return allow(f.IncludeEdges, f.ExcludeEdges, e.ID, e.Attributes)
+}
+
+func allow(include, exclude []Predicate, id string, attrs map[string]interface{}) bool {
+	for _, p := range include {
+		if !p.match(id, attrs) {
+			return false
+		}
+	}
+
+	for _, p := range exclude {
+		if p.match(id, attrs) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (p Predicate) match(id string, attrs map[string]interface{}) bool {
+	if len(p.IDs) > 0 && !slices.Contains(p.IDs, id) {
+		return false
+	}
+
+	if p.Attribute == "" {
+		return true
+	}
+
+	v, ok := attrs[p.Attribute]
+
+	switch p.Op {
+	case OpExists:
+		return ok
+	case "", OpEq:
+		return ok && v == p.Value
+	case OpNe:
+		return !ok || v != p.Value
+	}
+
+	// ordered comparison of numbers, other values never match
+	a, ok := v.(float64)
+	if !ok {
+		return false
+	}
+	b, ok := p.Value.(float64)
+	if !ok {
+		return false
+	}
+
+	switch p.Op {
+	case OpLt:
+		return a < b
+	case OpLte:
+		return a <= b
+	case OpGt:
+		return a > b
+	case OpGte:
+		return a >= b
+	}
+
+	return false
+}
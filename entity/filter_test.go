@@ -0,0 +1,110 @@
+package entity
+
+import "testing"
+
+func TestPredicateMatch(t *testing.T) {
+	attrs := map[string]interface{}{"lanes": 2.0, "kind": "road", "toll": true}
+
+	tests := []struct {
+		name      string
+		predicate Predicate
+		id        string
+		expected  bool
+	}{
+		{name: "id", predicate: Predicate{IDs: []string{"a", "b"}}, id: "b", expected: true},
+		{name: "other id", predicate: Predicate{IDs: []string{"a", "b"}}, id: "c", expected: false},
+		{name: "default op", predicate: Predicate{Attribute: "kind", Value: "road"}, expected: true},
+		{name: "eq", predicate: Predicate{Attribute: "lanes", Op: OpEq, Value: 2.0}, expected: true},
+		{name: "eq other", predicate: Predicate{Attribute: "lanes", Op: OpEq, Value: 3.0}, expected: false},
+		{name: "eq missing", predicate: Predicate{Attribute: "speed", Op: OpEq, Value: 3.0}, expected: false},
+		{name: "ne", predicate: Predicate{Attribute: "kind", Op: OpNe, Value: "rail"}, expected: true},
+		{name: "ne same", predicate: Predicate{Attribute: "kind", Op: OpNe, Value: "road"}, expected: false},
+		{name: "ne missing", predicate: Predicate{Attribute: "speed", Op: OpNe, Value: 3.0}, expected: true},
+		{name: "lt", predicate: Predicate{Attribute: "lanes", Op: OpLt, Value: 3.0}, expected: true},
+		{name: "lt equal", predicate: Predicate{Attribute: "lanes", Op: OpLt, Value: 2.0}, expected: false},
+		{name: "lte", predicate: Predicate{Attribute: "lanes", Op: OpLte, Value: 2.0}, expected: true},
+		{name: "lte less", predicate: Predicate{Attribute: "lanes", Op: OpLte, Value: 1.0}, expected: false},
+		{name: "gt", predicate: Predicate{Attribute: "lanes", Op: OpGt, Value: 1.0}, expected: true},
+		{name: "gt equal", predicate: Predicate{Attribute: "lanes", Op: OpGt, Value: 2.0}, expected: false},
+		{name: "gte", predicate: Predicate{Attribute: "lanes", Op: OpGte, Value: 2.0}, expected: true},
+		{name: "gte greater", predicate: Predicate{Attribute: "lanes", Op: OpGte, Value: 3.0}, expected: false},
+		{name: "exists", predicate: Predicate{Attribute: "toll", Op: OpExists}, expected: true},
+		{name: "exists missing", predicate: Predicate{Attribute: "speed", Op: OpExists}, expected: false},
+		{name: "string value with lt", predicate: Predicate{Attribute: "kind", Op: OpLt, Value: "z"}, expected: false},
+		{name: "number against string attribute", predicate: Predicate{Attribute: "kind", Op: OpGt, Value: 1.0}, expected: false},
+		{name: "number against bool attribute", predicate: Predicate{Attribute: "toll", Op: OpEq, Value: 1.0}, expected: false},
+		{name: "number against bool attribute with gte", predicate: Predicate{Attribute: "toll", Op: OpGte, Value: 0.0}, expected: false},
+		{name: "bool", predicate: Predicate{Attribute: "toll", Value: true}, expected: true},
+		{name: "id and attribute", predicate: Predicate{IDs: []string{"a"}, Attribute: "kind", Value: "road"}, id: "a", expected: true},
+		{name: "id and other attribute", predicate: Predicate{IDs: []string{"a"}, Attribute: "kind", Value: "rail"}, id: "a", expected: false},
+		{name: "other id and attribute", predicate: Predicate{IDs: []string{"a"}, Attribute: "kind", Value: "road"}, id: "b", expected: false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.predicate.match(tt.id, attrs); got != tt.expected {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestFilterAllow(t *testing.T) {
+	g := Graph{
+		Nodes: map[string]Node{
+			"a": {Attributes: map[string]interface{}{"kind": "city", "size": 3.0}},
+			"b": {Attributes: map[string]interface{}{"kind": "city", "size": 1.0}},
+			"c": {Attributes: map[string]interface{}{"kind": "village"}},
+		},
+	}
+	city := Predicate{Attribute: "kind", Value: "city"}
+	small := Predicate{Attribute: "size", Op: OpLt, Value: 2.0}
+
+	tests := []struct {
+		name     string
+		filter   Filter
+		expected map[string]bool
+	}{
+		{name: "zero filter", expected: map[string]bool{"a": true, "b": true, "c": true, "d": true}},
+		{name: "include", filter: Filter{IncludeNodes: []Predicate{city}}, expected: map[string]bool{"a": true, "b": true, "c": false, "d": false}},
+		{name: "exclude", filter: Filter{ExcludeNodes: []Predicate{city}}, expected: map[string]bool{"a": false, "b": false, "c": true, "d": true}},
+		{name: "all includes", filter: Filter{IncludeNodes: []Predicate{city, small}}, expected: map[string]bool{"a": false, "b": true, "c": false}},
+		{name: "exclude wins over include", filter: Filter{IncludeNodes: []Predicate{city}, ExcludeNodes: []Predicate{small}}, expected: map[string]bool{"a": true, "b": false, "c": false}},
+		{name: "exclude by id", filter: Filter{IncludeNodes: []Predicate{city}, ExcludeNodes: []Predicate{{IDs: []string{"a"}}}}, expected: map[string]bool{"a": false, "b": true, "c": false}},
+		{name: "edge predicates only", filter: Filter{ExcludeEdges: []Predicate{city}}, expected: map[string]bool{"a": true, "b": true, "c": true}},
+	}
+
+	for _, tt := range tests {
+		for id, expected := range tt.expected {
+			if got := tt.filter.allowNode(g, id); got != expected {
+				t.Errorf("%s: node %s: expected %v, got %v", tt.name, id, expected, got)
+			}
+		}
+	}
+}
+
+func TestFilterAllowEdge(t *testing.T) {
+	edges := []Edge{
+		{ID: "e1", Attributes: map[string]interface{}{"toll": true}},
+		{ID: "e2", Attributes: map[string]interface{}{"toll": false}},
+		{ID: "e3"},
+	}
+
+	tests := []struct {
+		name     string
+		filter   Filter
+		expected []bool
+	}{
+		{name: "zero filter", expected: []bool{true, true, true}},
+		{name: "include", filter: Filter{IncludeEdges: []Predicate{{Attribute: "toll", Op: OpExists}}}, expected: []bool{true, true, false}},
+		{name: "exclude", filter: Filter{ExcludeEdges: []Predicate{{Attribute: "toll", Value: true}}}, expected: []bool{false, true, true}},
+		{name: "exclude wins over include", filter: Filter{IncludeEdges: []Predicate{{IDs: []string{"e1", "e2"}}}, ExcludeEdges: []Predicate{{IDs: []string{"e2"}}}}, expected: []bool{true, false, false}},
+		{name: "node predicates only", filter: Filter{ExcludeNodes: []Predicate{{IDs: []string{"e1"}}}}, expected: []bool{true, true, true}},
+	}
+
+	for _, tt := range tests {
+		for i, e := range edges {
+			if got := tt.filter.allowEdge(e); got != tt.expected[i] {
+				t.Errorf("%s: edge %s: expected %v, got %v", tt.name, e.ID, tt.expected[i], got)
+			}
+		}
+	}
+}
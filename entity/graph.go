@@ -1,92 +1,249 @@
 package entity
 
 import (
+	"container/heap"
+	"context"
+	"errors"
 	"graphs/entity/postgre"
-	"sort"
+	"slices"
 )
 
 type (
+	// Edge is identified by ID, parallel edges between the same nodes are kept as separate edges
 	Edge struct {
-		Next string
-		Cost float64
+		ID         string
+		Name       string
+		Next       string
+		Cost       float64
+		Attributes map[string]interface{}
+	}
+
+	Node struct {
+		Name       string
+		Attributes map[string]interface{}
 	}
 
 	Graph struct {
+		ID            string
+		Name          string
+		Nodes         map[string]Node
 		AdjacencyList map[string][]Edge
+		// ReverseList has edges coming into node, Edge.Next is the node edge comes from
+		ReverseList map[string][]Edge
+		// NegativeCosts switches cheapest path searches from Dijkstra's to Bellman-Ford algorithm
+		NegativeCosts bool
 	}
 
+	// PathsCost is a path with edges taken, Edges[i] is the edge ID from Path[i] to Path[i+1]
 	PathsCost struct {
 		Path      []string
+		Edges     []string
 		TotalCost float64
 	}
+
+	// PathsLimit bounds GetPaths search, zero fields are not limited
+	PathsLimit struct {
+		Limit    int     // max paths returned
+		Offset   int     // paths skipped before the first returned
+		MaxDepth int     // max edges in path
+		MaxCost  float64 // max total cost of path
+	}
+
+	// step is the edge taken to reach node
+	step struct {
+		node string // previous node
+		edge string
+	}
+
+	// edgeKey identifies an edge of the adjacency list, edge IDs are unique only within the graph
+	edgeKey struct {
+		from, to, id string
+	}
+
+	pathsSearch struct {
+		canceller *canceller
+		filter    Filter
+		limit     PathsLimit
+		found     int // paths found including skipped by offset
+		truncated bool
+	}
 )
 
+// errStopSearch stops DFS when enough paths are found
+var errStopSearch = errors.New("stop search")
+
 func NewGraph(graphDB postgre.Graph) *Graph {
-	graph := Graph{AdjacencyList: make(map[string][]Edge, len(graphDB.Nodes))}
+	graph := Graph{
+		ID:            graphDB.ID,
+		Name:          graphDB.Name,
+		Nodes:         make(map[string]Node, len(graphDB.Nodes)),
+		AdjacencyList: make(map[string][]Edge, len(graphDB.Nodes)),
+	}
 
 	for _, n := range graphDB.Nodes {
+		graph.Nodes[n.ID] = Node{Name: n.Name, Attributes: n.Attributes}
 		graph.AdjacencyList[n.ID] = nil
 	}
 
 	for _, e := range graphDB.Edges {
-		f, ok := graph.AdjacencyList[e.PreviousNode]
-		if ok {
-			if f == nil {
-				f = []Edge{{Next: e.NextNode, Cost: e.Cost}}
-			} else {
-				f = append(f, Edge{Next: e.NextNode, Cost: e.Cost})
-			}
+		graph.addEdge(e.PreviousNode, Edge{ID: e.ID, Name: e.Name, Next: e.NextNode, Cost: e.Cost, Attributes: e.Attributes})
 
-			graph.AdjacencyList[e.PreviousNode] = f
+		// bidirectional edge is traversed back under the same ID
+		if e.Bidirectional {
+			graph.addEdge(e.NextNode, Edge{ID: e.ID, Name: e.Name, Next: e.PreviousNode, Cost: e.Cost, Attributes: e.Attributes})
 		}
 	}
 
+	graph.ReverseList = reverseAdjacency(graph.AdjacencyList)
+	graph.NegativeCosts = hasNegativeCost(graph.AdjacencyList)
+
 	return &graph
 }
 
-func (g Graph) GetPaths(start, end string) [][]string {
+// addEdge appends edge going out of node from, edges of unknown nodes are dropped
+func (g *Graph) addEdge(from string, e Edge) {
+	f, ok := g.AdjacencyList[from]
+	if ok {
+		g.AdjacencyList[from] = append(f, e)
+	}
+}
+
+// HasNode reports node is in the graph
+func (g Graph) HasNode(id string) bool {
+	_, ok := g.AdjacencyList[id]
+
+	return ok
+}
+
+// Edge returns edge id going out of node from
+func (g Graph) Edge(from, id string) (Edge, bool) {
+	for _, e := range g.AdjacencyList[from] {
+		if e.ID == id {
+			return e, true
+		}
+	}
+
+	return Edge{}, false
+}
+
+// GetPaths returns simple paths from start to end in search order, bounded by limit.
+// Only nodes and edges allowed by filter are traversed.
+// Reports truncated if there are more paths than limit.Limit after limit.Offset.
+// Search is stopped with ctx error when ctx is done.
+func (g Graph) GetPaths(ctx context.Context, start, end string, limit PathsLimit, filter Filter) ([]PathsCost, bool, error) {
 	var (
 		cost, totalCost float64
 		visited         = make(map[string]int)
 		path            = make([]string, 0)
+		edges           = make([]string, 0)
 		allPaths        = make([]PathsCost, 0)
-		response        = make([][]string, 0)
+		search          = pathsSearch{canceller: newCanceller(ctx), filter: filter, limit: limit}
 	)
 
-	g.dfsAllPathsWithCost(start, end, visited, path, cost, totalCost, &allPaths)
+	if !filter.allowNode(g, start) {
+		return allPaths, false, nil
+	}
 
-	for _, p := range allPaths {
-		response = append(response, p.Path)
+	err := g.dfsAllPathsWithCost(&search, start, end, visited, path, edges, cost, totalCost, &allPaths)
+	if err != nil && !errors.Is(err, errStopSearch) {
+		return nil, false, err
 	}
 
-	return response
+	return allPaths, search.truncated, nil
+}
+
+// GetCheapestPaths returns the cheapest path from start to end with its total cost.
+// Dijkstra's algorithm on a binary heap, stops as soon as end is settled.
+// Only nodes and edges allowed by filter are traversed, if end is unreachable it returns nil path.
+func (g Graph) GetCheapestPaths(ctx context.Context, start, end string, filter Filter) (*PathsCost, error) {
+	return g.shortestPath(newCanceller(ctx), filter, start, end, nil, nil)
 }
 
-func (g Graph) GetCheapestPaths(start, end string) []string {
+// shortestPath is Dijkstra's search that skips nodes and edges not allowed by filter, removedNodes and removedEdges,
+// a removed edge does not hide parallel edges between the same nodes. Graph with negative costs is searched by Bellman-Ford.
+func (g Graph) shortestPath(c *canceller, filter Filter, start, end string, removedNodes map[string]bool, removedEdges map[edgeKey]bool) (*PathsCost, error) {
+	if g.NegativeCosts {
+		return g.shortestPathNegative(c, filter, start, end, removedNodes, removedEdges)
+	}
+
+	if _, ok := g.AdjacencyList[start]; !ok || removedNodes[start] || !filter.allowNode(g, start) {
+		return nil, nil
+	}
+
 	var (
-		cost, totalCost float64
-		visited         = make(map[string]int)
-		path            = make([]string, 0)
-		allPaths        = make([]PathsCost, 0)
+		dist     = map[string]float64{start: 0}
+		previous = make(map[string]step)
+		settled  = make(map[string]bool)
+		queue    = &priorityQueue{{node: start, cost: 0}}
 	)
 
-	g.dfsAllPathsWithCost(start, end, visited, path, cost, totalCost, &allPaths)
+	for queue.Len() > 0 {
+		if err := c.err(); err != nil {
+			return nil, err
+		}
 
-	// If it has no path return nil
-	if len(allPaths) == 0 {
-		return nil
+		item := heap.Pop(queue).(queueItem)
+		if settled[item.node] {
+			// stale queue entry, node already reached with lower cost
+			continue
+		}
+		settled[item.node] = true
+
+		// early exit, the target cost is final once it leaves the queue
+		if item.node == end {
+			path := buildPath(previous, start, end)
+			path.TotalCost = item.cost
+
+			return &path, nil
+		}
+
+		for _, next := range g.AdjacencyList[item.node] {
+			if settled[next.Next] || removedNodes[next.Next] || removedEdges[edgeKey{from: item.node, to: next.Next, id: next.ID}] {
+				continue
+			}
+
+			if !filter.allowEdge(next) || !filter.allowNode(g, next.Next) {
+				continue
+			}
+
+			cost := item.cost + next.Cost
+			if d, ok := dist[next.Next]; !ok || cost < d {
+				dist[next.Next] = cost
+				previous[next.Next] = step{node: item.node, edge: next.ID}
+				heap.Push(queue, queueItem{node: next.Next, cost: cost})
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// buildPath restores the path from start to end by walking predecessors back.
+func buildPath(previous map[string]step, start, end string) PathsCost {
+	var (
+		path  = []string{end}
+		edges = make([]string, 0)
+	)
+
+	for current := end; current != start; {
+		s := previous[current]
+		current = s.node
+		path = append(path, current)
+		edges = append(edges, s.edge)
 	}
 
-	// Sort slice from min to max TotalCost
-	sort.Slice(allPaths, func(i, j int) bool {
-		return allPaths[i].TotalCost < allPaths[j].TotalCost
-	})
+	// reverse to start -> end order
+	slices.Reverse(path)
+	slices.Reverse(edges)
 
-	// return minimum coast path
-	return allPaths[0].Path
+	return PathsCost{Path: path, Edges: edges}
 }
 
-func (g Graph) dfsAllPathsWithCost(current, finish string, visited map[string]int, path []string, cost, totalCost float64, allPaths *[]PathsCost) {
+func (g Graph) dfsAllPathsWithCost(s *pathsSearch, current, finish string, visited map[string]int, path, edges []string, cost, totalCost float64, allPaths *[]PathsCost) error {
+	if err := s.canceller.err(); err != nil {
+		return err
+	}
+
 	visited[current] = 1
 
 	path = append(path, current)
@@ -94,11 +251,31 @@ func (g Graph) dfsAllPathsWithCost(current, finish string, visited map[string]in
 
 	// If the current node is the target, return the path
 	if current == finish {
-		*allPaths = append(*allPaths, PathsCost{Path: path, TotalCost: totalCost})
-	} else {
+		// with negative costs path over max cost is not pruned, its rest may be cheaper
+		if s.limit.MaxCost > 0 && totalCost > s.limit.MaxCost {
+			visited[current] = 2
+			return nil
+		}
+
+		if err := s.add(PathsCost{Path: slices.Clone(path), Edges: slices.Clone(edges), TotalCost: totalCost}, allPaths); err != nil {
+			return err
+		}
+	} else if s.limit.MaxDepth <= 0 || len(path) <= s.limit.MaxDepth {
 		for _, next := range g.AdjacencyList[current] {
+			// path over max cost, skip the edge
+			if s.limit.MaxCost > 0 && !g.NegativeCosts && totalCost+next.Cost > s.limit.MaxCost {
+				continue
+			}
+
+			if !s.filter.allowEdge(next) || !s.filter.allowNode(g, next.Next) {
+				continue
+			}
+
 			if visited[next.Next] != 1 {
-				g.dfsAllPathsWithCost(next.Next, finish, visited, path, next.Cost, totalCost, allPaths)
+				err := g.dfsAllPathsWithCost(s, next.Next, finish, visited, path, append(edges, next.ID), next.Cost, totalCost, allPaths)
+				if err != nil {
+					return err
+				}
 			} else if visited[next.Next] == 1 {
 				// path has cycle skip the path
 				continue
@@ -107,4 +284,23 @@ func (g Graph) dfsAllPathsWithCost(current, finish string, visited map[string]in
 	}
 
 	visited[current] = 2
+
+	return nil
+}
+
+// add keeps found path if it is in the requested page, stops search with errStopSearch after the page
+func (s *pathsSearch) add(path PathsCost, allPaths *[]PathsCost) error {
+	s.found++
+	if s.found <= s.limit.Offset {
+		return nil
+	}
+
+	if s.limit.Limit > 0 && len(*allPaths) == s.limit.Limit {
+		s.truncated = true
+		return errStopSearch
+	}
+
+	*allPaths = append(*allPaths, path)
+
+	return nil
 }
@@ -0,0 +1,112 @@
+package entity
+
+import (
+	"context"
+	"graphs/entity/postgre"
+	"math/rand"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+// randomGraph builds a graph of nodes named "0".."nodes-1" with up to edges edges of cost 1..9,
+// parallel edges are kept and every fifth edge is bidirectional on average
+func randomGraph(rnd *rand.Rand, nodes, edges int) *Graph {
+	graph := postgre.Graph{ID: "g"}
+	for i := 0; i < nodes; i++ {
+		graph.Nodes = append(graph.Nodes, postgre.Node{ID: strconv.Itoa(i)})
+	}
+
+	for i := 0; i < edges; i++ {
+		from, to := rnd.Intn(nodes), rnd.Intn(nodes)
+		if from == to {
+			continue
+		}
+
+		graph.Edges = append(graph.Edges, postgre.Edge{
+			ID:            "e" + strconv.Itoa(i),
+			PreviousNode:  strconv.Itoa(from),
+			NextNode:      strconv.Itoa(to),
+			Cost:          float64(rnd.Intn(9) + 1),
+			Bidirectional: rnd.Intn(5) == 0,
+		})
+	}
+
+	return NewGraph(graph)
+}
+
+// simplePaths enumerates every simple path from start to end by brute force, cheapest first
+func simplePaths(g *Graph, start, end string) []PathsCost {
+	var (
+		paths   []PathsCost
+		visited = map[string]bool{start: true}
+		walk    func(path, edges []string, cost float64)
+	)
+
+	walk = func(path, edges []string, cost float64) {
+		n := path[len(path)-1]
+		if n == end {
+			paths = append(paths, PathsCost{Path: slices.Clone(path), Edges: slices.Clone(edges), TotalCost: cost})
+			return
+		}
+
+		for _, e := range g.AdjacencyList[n] {
+			if visited[e.Next] {
+				continue
+			}
+
+			visited[e.Next] = true
+			walk(append(path, e.Next), append(edges, e.ID), cost+e.Cost)
+			visited[e.Next] = false
+		}
+	}
+	walk([]string{start}, nil, 0)
+
+	sort.SliceStable(paths, func(i, j int) bool { return paths[i].TotalCost < paths[j].TotalCost })
+
+	return paths
+}
+
+func TestGetPathsPagesMatchUnpaged(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	for run := 0; run < 100; run++ {
+		graph := randomGraph(rnd, 7, 18)
+
+		all, truncated, err := graph.GetPaths(context.Background(), "0", "6", PathsLimit{}, Filter{})
+		if err != nil || truncated {
+			t.Fatalf("run %d: expected all paths, got truncated %t, %v", run, truncated, err)
+		}
+
+		if expected := simplePaths(graph, "0", "6"); len(all) != len(expected) {
+			t.Fatalf("run %d: expected %d paths, got %d", run, len(expected), len(all))
+		}
+
+		for _, limit := range []int{1, 2, 3} {
+			var pages []PathsCost
+
+			for offset := 0; ; offset += limit {
+				page, truncated, err := graph.GetPaths(context.Background(), "0", "6", PathsLimit{Limit: limit, Offset: offset}, Filter{})
+				if err != nil {
+					t.Fatalf("run %d: unexpected error %v", run, err)
+				}
+				pages = append(pages, page...)
+
+				// the last full page is not truncated
+				if more := offset+limit < len(all); truncated != more {
+					t.Fatalf("run %d, limit %d, offset %d: expected truncated %t of %d paths", run, limit, offset, more, len(all))
+				}
+
+				if !truncated {
+					break
+				}
+			}
+
+			if len(pages) != len(all) || (len(all) > 0 && !reflect.DeepEqual(pages, all)) {
+				t.Errorf("run %d, limit %d: pages differ from unpaged paths\nexpected: %v\ngot: %v", run, limit, all, pages)
+			}
+		}
+	}
+}
@@ -0,0 +1,321 @@
+package graphml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"graphs/entity/postgre"
+	xmlentity "graphs/entity/xml"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const Namespace = "http://graphml.graphdrawing.org/xmlns"
+
+// Attribute names mapped on graph, node and edge fields. Gephi writes node names as "label".
+const (
+	attrName  = "name"
+	attrLabel = "label"
+	attrCost  = "cost"
+)
+
+// Values of graph edgedefault attribute
+const (
+	edgeDirected   = "directed"
+	edgeUndirected = "undirected"
+)
+
+type (
+	GraphML struct {
+		XMLName xml.Name `xml:"graphml"`
+		XMLNS   string   `xml:"xmlns,attr,omitempty"`
+		Keys    []Key    `xml:"key"`
+		Graph   Graph    `xml:"graph"`
+	}
+
+	Key struct {
+		ID       string `xml:"id,attr"`
+		For      string `xml:"for,attr"`
+		AttrName string `xml:"attr.name,attr"`
+		AttrType string `xml:"attr.type,attr,omitempty"`
+	}
+
+	Graph struct {
+		ID          string `xml:"id,attr"`
+		EdgeDefault string `xml:"edgedefault,attr"`
+		Data        []Data `xml:"data"`
+		Nodes       []Node `xml:"node"`
+		Edges       []Edge `xml:"edge"`
+	}
+
+	Node struct {
+		ID   string `xml:"id,attr"`
+		Data []Data `xml:"data"`
+	}
+
+	Edge struct {
+		ID       string `xml:"id,attr,omitempty"`
+		Source   string `xml:"source,attr"`
+		Target   string `xml:"target,attr"`
+		Directed string `xml:"directed,attr,omitempty"`
+		Data     []Data `xml:"data"`
+	}
+
+	Data struct {
+		Key   string `xml:"key,attr"`
+		Value string `xml:",chardata"`
+	}
+)
+
+// Decode reads GraphML document and maps it on the graph format of graph.xml,
+// so the result passes the same validation. Graph, node and edge names are taken from
+// "name" or "label" attributes and edge cost from "cost" attribute, other node and edge
+// data are kept as attributes typed by attr.type of their keys.
+// Undirected edges, by edgedefault of the graph or directed attribute of the edge, are bidirectional.
+func Decode(r io.Reader) (*xmlentity.Graph, error) {
+	var doc GraphML
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("error unmarshalling GraphML: %w", err)
+	}
+
+	// key ID -> key per element kind
+	keys := make(map[string]map[string]Key)
+	for _, k := range doc.Keys {
+		if keys[k.For] == nil {
+			keys[k.For] = make(map[string]Key)
+		}
+		keys[k.For][k.ID] = k
+	}
+
+	graph := xmlentity.Graph{
+		ID:   doc.Graph.ID,
+		Name: findData(doc.Graph.Data, keys, "graph", attrName, attrLabel),
+	}
+
+	if doc.Graph.EdgeDefault == edgeUndirected {
+		directed := false
+		graph.Directed = &directed
+	}
+
+	for _, n := range doc.Graph.Nodes {
+		graph.Nodes.Nodes = append(graph.Nodes.Nodes, xmlentity.Node{
+			ID:         n.ID,
+			Name:       findData(n.Data, keys, "node", attrName, attrLabel),
+			Attributes: findAttributes(n.Data, keys, "node", attrName, attrLabel),
+		})
+	}
+
+	for _, e := range doc.Graph.Edges {
+		edge := xmlentity.Edge{
+			ID:         e.ID,
+			Name:       findData(e.Data, keys, "edge", attrName, attrLabel),
+			From:       e.Source,
+			To:         e.Target,
+			Attributes: findAttributes(e.Data, keys, "edge", attrName, attrLabel, attrCost),
+		}
+
+		// pretty-printed documents may pad values with whitespace
+		if cost := strings.TrimSpace(findData(e.Data, keys, "edge", attrCost)); cost != "" {
+			c, err := strconv.ParseFloat(cost, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid cost %q of edge %s: %w", cost, e.ID, err)
+			}
+			edge.Cost = c
+		}
+
+		if e.Directed != "" {
+			directed, err := strconv.ParseBool(strings.TrimSpace(e.Directed))
+			if err != nil {
+				return nil, fmt.Errorf("invalid directed %q of edge %s: %w", e.Directed, e.ID, err)
+			}
+			edge.Directed = &directed
+		}
+
+		graph.Edges.Edges = append(graph.Edges.Edges, edge)
+	}
+
+	return &graph, nil
+}
+
+// Encode writes graph as GraphML document.
+func Encode(w io.Writer, graph postgre.Graph) error {
+	doc := GraphML{
+		XMLNS: Namespace,
+		Keys: []Key{
+			{ID: "g_name", For: "graph", AttrName: attrName, AttrType: "string"},
+			{ID: "n_name", For: "node", AttrName: attrName, AttrType: "string"},
+			{ID: "e_name", For: "edge", AttrName: attrName, AttrType: "string"},
+			{ID: "e_cost", For: "edge", AttrName: attrCost, AttrType: "double"},
+		},
+		Graph: Graph{
+			ID:          graph.ID,
+			EdgeDefault: edgeDirected,
+			Data:        []Data{{Key: "g_name", Value: graph.Name}},
+			Nodes:       make([]Node, 0, len(graph.Nodes)),
+			Edges:       make([]Edge, 0, len(graph.Edges)),
+		},
+	}
+
+	var (
+		nodeKeys = newAttributeKeys("node", "n_attr_")
+		edgeKeys = newAttributeKeys("edge", "e_attr_")
+	)
+
+	for _, n := range graph.Nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, Node{
+			ID:   n.ID,
+			Data: append([]Data{{Key: "n_name", Value: n.Name}}, nodeKeys.data(n.Attributes)...),
+		})
+	}
+
+	for _, e := range graph.Edges {
+		edge := Edge{
+			ID:     e.ID,
+			Source: e.PreviousNode,
+			Target: e.NextNode,
+			Data: []Data{
+				{Key: "e_name", Value: e.Name},
+				{Key: "e_cost", Value: strconv.FormatFloat(e.Cost, 'f', -1, 64)},
+			},
+		}
+		edge.Data = append(edge.Data, edgeKeys.data(e.Attributes)...)
+		if e.Bidirectional {
+			edge.Directed = "false"
+		}
+
+		doc.Graph.Edges = append(doc.Graph.Edges, edge)
+	}
+
+	doc.Keys = append(doc.Keys, nodeKeys.keys()...)
+	doc.Keys = append(doc.Keys, edgeKeys.keys()...)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("error marshalling GraphML: %w", err)
+	}
+
+	return enc.Close()
+}
+
+// findData returns value of the first data element named by one of attrNames
+func findData(data []Data, keys map[string]map[string]Key, kind string, attrNames ...string) string {
+	for _, name := range attrNames {
+		for _, d := range data {
+			if keyName(keys, kind, d.Key) == name {
+				return d.Value
+			}
+		}
+	}
+
+	return ""
+}
+
+// keyName resolves data key to attribute name, keys declared for "all" apply to every element.
+// Undeclared keys are taken as attribute names.
+func keyName(keys map[string]map[string]Key, kind, key string) string {
+	return findKey(keys, kind, key).AttrName
+}
+
+func findKey(keys map[string]map[string]Key, kind, key string) Key {
+	if k, ok := keys[kind][key]; ok {
+		return k
+	}
+
+	if k, ok := keys["all"][key]; ok {
+		return k
+	}
+
+	return Key{ID: key, For: kind, AttrName: key}
+}
+
+// findAttributes returns data elements except ones mapped on fieldNames as typed attributes
+func findAttributes(data []Data, keys map[string]map[string]Key, kind string, fieldNames ...string) xmlentity.Attributes {
+	var attrs xmlentity.Attributes
+
+	for _, d := range data {
+		k := findKey(keys, kind, d.Key)
+		if slices.Contains(fieldNames, k.AttrName) {
+			continue
+		}
+
+		attrs.Attributes = append(attrs.Attributes, xmlentity.Attribute{
+			Name:  k.AttrName,
+			Type:  attributeType(k.AttrType),
+			Value: d.Value,
+		})
+	}
+
+	return attrs
+}
+
+// attributeType maps GraphML attr.type on attribute type
+func attributeType(attrType string) string {
+	switch attrType {
+	case "int", "long", "float", "double":
+		return xmlentity.AttributeNumber
+	case "boolean":
+		return xmlentity.AttributeBool
+	}
+
+	return xmlentity.AttributeString
+}
+
+// attributeKeys declares a key per attribute name of nodes or edges written by Encode
+type attributeKeys struct {
+	kind   string
+	prefix string
+	types  map[string]string // attribute name -> attr.type
+}
+
+func newAttributeKeys(kind, prefix string) *attributeKeys {
+	return &attributeKeys{kind: kind, prefix: prefix, types: make(map[string]string)}
+}
+
+// data returns attributes as data elements sorted by name and records their types
+func (k *attributeKeys) data(attrs postgre.Attributes) []Data {
+	names := make([]string, 0, len(attrs))
+	for name := range attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	data := make([]Data, 0, len(names))
+	for _, name := range names {
+		value := attrs[name]
+
+		attrType := "string"
+		switch value.(type) {
+		case float64:
+			attrType = "double"
+		case bool:
+			attrType = "boolean"
+		}
+
+		// attribute with values of different types is written as string
+		if t, ok := k.types[name]; ok && t != attrType {
+			attrType = "string"
+		}
+		k.types[name] = attrType
+
+		data = append(data, Data{Key: k.prefix + name, Value: fmt.Sprint(value)})
+	}
+
+	return data
+}
+
+func (k *attributeKeys) keys() []Key {
+	keys := make([]Key, 0, len(k.types))
+	for name, attrType := range k.types {
+		keys = append(keys, Key{ID: k.prefix + name, For: k.kind, AttrName: name, AttrType: attrType})
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+
+	return keys
+}
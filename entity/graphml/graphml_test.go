@@ -0,0 +1,67 @@
+package graphml
+
+import (
+	"bytes"
+	"graphs/entity/postgre"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	graph := postgre.Graph{
+		ID:   "g0",
+		Name: "The Graph Name",
+		Nodes: []postgre.Node{
+			{ID: "a", Name: "A name", GraphID: "g0", Attributes: postgre.Attributes{"capacity": 10.0, "owner": "ACME", "closed": false}},
+			{ID: "b", Name: "B name", GraphID: "g0"},
+		},
+		Edges: []postgre.Edge{
+			{ID: "a1", Name: "road", PreviousNode: "a", NextNode: "b", Cost: 3.5, GraphID: "g0", Attributes: postgre.Attributes{"mode": "rail"}},
+			{ID: "b1", PreviousNode: "b", NextNode: "a", Cost: 42, GraphID: "g0", Bidirectional: true},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, graph); err != nil {
+		t.Fatalf("unexpected encode error %v", err)
+	}
+
+	decoded, err := Decode(&buf)
+	if err != nil {
+		t.Fatalf("unexpected decode error %v", err)
+	}
+
+	if err := decoded.Validate(false); err != nil {
+		t.Fatalf("unexpected validation error %v", err)
+	}
+
+	if got := postgre.NewGraph(*decoded); !reflect.DeepEqual(*got, graph) {
+		t.Errorf("expected %+v, got %+v", graph, *got)
+	}
+}
+
+func TestDecodePaddedValues(t *testing.T) {
+	doc := `<graphml xmlns="http://graphml.graphdrawing.org/xmlns">
+  <key id="c" for="edge" attr.name="cost" attr.type="double"/>
+  <graph id="g0" edgedefault="directed">
+    <node id="a"/>
+    <node id="b"/>
+    <edge id="a1" source="a" target="b" directed=" false ">
+      <data key="c">
+        3.5
+      </data>
+    </edge>
+  </graph>
+</graphml>`
+
+	graph, err := Decode(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("unexpected decode error %v", err)
+	}
+
+	edge := graph.Edges.Edges[0]
+	if edge.Cost != 3.5 || graph.IsDirected(edge) {
+		t.Errorf("expected undirected edge of cost 3.5, got %+v", edge)
+	}
+}
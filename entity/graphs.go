@@ -0,0 +1,34 @@
+package entity
+
+import "graphs/entity/postgre"
+
+// Graphs is a set of in-memory graphs by ID.
+// Default graph answers requests which don't name graph.
+type Graphs struct {
+	Default string
+	Graphs  map[string]*Graph
+}
+
+func NewGraphs(defaultID string, graphsDB []postgre.Graph) *Graphs {
+	graphs := Graphs{
+		Default: defaultID,
+		Graphs:  make(map[string]*Graph, len(graphsDB)),
+	}
+
+	for _, g := range graphsDB {
+		graphs.Graphs[g.ID] = NewGraph(g)
+	}
+
+	return &graphs
+}
+
+// Get returns graph by ID, empty ID means default graph.
+func (g *Graphs) Get(id string) (*Graph, bool) {
+	if id == "" {
+		id = g.Default
+	}
+
+	graph, ok := g.Graphs[id]
+
+	return graph, ok
+}
@@ -0,0 +1,64 @@
+package entity
+
+import (
+	"graphs/entity/postgre"
+	"sync"
+	"testing"
+)
+
+func TestGraphsGet(t *testing.T) {
+	graphs := NewGraphs("g0", []postgre.Graph{
+		{ID: "g0", Nodes: []postgre.Node{{ID: "a"}}},
+		{ID: "g1", Nodes: []postgre.Node{{ID: "b"}}},
+	})
+
+	tests := []struct {
+		id   string
+		node string // node of the expected graph, empty if graph is not found
+	}{
+		{id: "", node: "a"},
+		{id: "g0", node: "a"},
+		{id: "g1", node: "b"},
+		{id: "g2"},
+	}
+
+	for _, tt := range tests {
+		graph, ok := graphs.Get(tt.id)
+		if ok != (tt.node != "") {
+			t.Fatalf("graph %q: expected found %t, got %t", tt.id, tt.node != "", ok)
+		}
+
+		if ok && !graph.HasNode(tt.node) {
+			t.Errorf("graph %q: expected graph with node %s, got %+v", tt.id, tt.node, graph)
+		}
+	}
+
+	// unknown default graph is not found either
+	if graph, ok := NewGraphs("g9", nil).Get(""); ok || graph != nil {
+		t.Errorf("expected no default graph, got %+v", graph)
+	}
+}
+
+func TestGraphsGetConcurrent(t *testing.T) {
+	graphs := NewGraphs("g0", []postgre.Graph{{ID: "g0"}, {ID: "g1"}})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for _, id := range []string{"", "g0", "g1", "g2"} {
+				graph, ok := graphs.Get(id)
+				if ok != (id != "g2") || ok != (graph != nil) {
+					t.Errorf("graph %q: unexpected %+v, %t", id, graph, ok)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if g0, _ := graphs.Get(""); g0 != graphs.Graphs["g0"] {
+		t.Errorf("expected default graph to be g0")
+	}
+}
@@ -0,0 +1,49 @@
+package entity
+
+// queueItem is a node waiting in the priority queue with its tentative cost.
+type queueItem struct {
+	node string
+	cost float64
+}
+
+// priorityQueue is a binary min-heap of queueItem ordered by cost.
+// It implements container/heap.Interface.
+type priorityQueue []queueItem
+
+func (pq priorityQueue) Len() int { return len(pq) }
+
+func (pq priorityQueue) Less(i, j int) bool { return pq[i].cost < pq[j].cost }
+
+func (pq priorityQueue) Swap(i, j int) { pq[i], pq[j] = pq[j], pq[i] }
+
+func (pq *priorityQueue) Push(x any) { *pq = append(*pq, x.(queueItem)) }
+
+func (pq *priorityQueue) Pop() any {
+	old := *pq
+	n := len(old)
+	item := old[n-1]
+	*pq = old[:n-1]
+
+	return item
+}
+
+// nodeQueue is a binary min-heap of node IDs.
+// It implements container/heap.Interface.
+type nodeQueue []string
+
+func (q nodeQueue) Len() int { return len(q) }
+
+func (q nodeQueue) Less(i, j int) bool { return q[i] < q[j] }
+
+func (q nodeQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *nodeQueue) Push(x any) { *q = append(*q, x.(string)) }
+
+func (q *nodeQueue) Pop() any {
+	old := *q
+	n := len(old)
+	node := old[n-1]
+	*q = old[:n-1]
+
+	return node
+}
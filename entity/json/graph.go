@@ -1,29 +1,296 @@
 package json
 
+// QueryError codes
+const (
+	ErrCodeEmptyQuery        = "empty_query"        // query has no known query type
+	ErrCodeInvalidQuery      = "invalid_query"      // required field is missing or out of range
+	ErrCodeUnknownNode       = "unknown_node"       // node is not in the graph
+	ErrCodeTimeout           = "timeout"            // query or request deadline exceeded
+	ErrCodeCanceled          = "canceled"           // request canceled, e.g. on shutdown
+	ErrCodeNegativeCycle     = "negative_cycle"     // negative cost cycle makes the cheapest path unbounded
+	ErrCodeBidirectionalEdge = "bidirectional_edge" // bidirectional edge can't be ordered by topo and critical_path
+	ErrCodeInternal          = "internal"
+
+	ErrCodeInvalidRequest = "invalid_request" // request is not a valid JSON document
+	ErrCodeUnknownGraph   = "unknown_graph"   // graph is not stored
+)
+
+// Protocol versions
+const (
+	VersionLegacy = 1 // paths are node ID arrays, default
+	VersionTyped  = 2 // paths are Path objects with edges, cost and hops
+)
+
 type (
 	PathQuery struct {
 		Start string `json:"start"`
 		End   string `json:"end"`
+
+		// paths query only, not limited if empty
+		Limit    int     `json:"limit,omitempty"`     // max paths in response
+		Offset   int     `json:"offset,omitempty"`    // paths skipped, next_offset of previous page
+		MaxDepth int     `json:"max_depth,omitempty"` // max edges in path
+		MaxCost  float64 `json:"max_cost,omitempty"`  // max total cost of path
+
+		// cheapest query only, waypoints visited in the given order unless via_any_order is set
+		Via         []string `json:"via,omitempty"` // [ "c", "e" ]
+		ViaAnyOrder bool     `json:"via_any_order,omitempty"`
+
+		Filter *Filter `json:"filter,omitempty"`
+	}
+
+	TopKQuery struct {
+		Start  string  `json:"start"`
+		End    string  `json:"end"`
+		K      int     `json:"k"`
+		Filter *Filter `json:"filter,omitempty"`
+	}
+
+	// Filter restricts nodes and edges a path may go through, applied during search.
+	// Node or edge must match all include predicates and none of exclude predicates.
+	Filter struct {
+		IncludeNodes []Predicate `json:"include_nodes,omitempty"`
+		ExcludeNodes []Predicate `json:"exclude_nodes,omitempty"`
+		IncludeEdges []Predicate `json:"include_edges,omitempty"`
+		ExcludeEdges []Predicate `json:"exclude_edges,omitempty"`
+	}
+
+	// Predicate matches node or edge ID in IDs and attribute compared with value by op, all set fields must match
+	Predicate struct {
+		IDs       []string    `json:"ids,omitempty"`       // [ "a", "b" ]
+		Attribute string      `json:"attribute,omitempty"` // "mode"
+		Op        string      `json:"op,omitempty"`        // eq (default), ne, lt, lte, gt, gte, exists
+		Value     interface{} `json:"value,omitempty"`     // "rail", 10 or true
+	}
+
+	CyclesQuery struct {
+		Limit int `json:"limit,omitempty"` // default limit if empty
+	}
+
+	// NodeQuery is reachable or ancestors query
+	NodeQuery struct {
+		Node   string  `json:"node"`
+		Filter *Filter `json:"filter,omitempty"`
+	}
+
+	// NeighbourhoodQuery answers nodes within both limits, the least hops and the least cost are searched separately,
+	// so they may come from different paths. Node within MaxCost only over more than MaxHops edges is not answered
+	NeighbourhoodQuery struct {
+		Node    string  `json:"node"`
+		MaxHops int     `json:"max_hops,omitempty"` // not limited if empty
+		MaxCost float64 `json:"max_cost,omitempty"` // not limited if empty
+		Reverse bool    `json:"reverse,omitempty"`  // nodes leading to node
+		Filter  *Filter `json:"filter,omitempty"`
+	}
+
+	SSSPQuery struct {
+		Origin string  `json:"origin"`
+		Filter *Filter `json:"filter,omitempty"`
+	}
+
+	MatrixQuery struct {
+		Origins      []string `json:"origins"`
+		Destinations []string `json:"destinations,omitempty"` // origins if empty
+		Filter       *Filter  `json:"filter,omitempty"`
+	}
+
+	TopoQuery struct{}
+
+	CriticalPathQuery struct{}
+
+	SCCQuery struct {
+		Condensation bool `json:"condensation,omitempty"` // answer condensation DAG edges as well
 	}
 
 	Query struct {
-		Paths    *PathQuery `json:"paths,omitempty"`
-		Cheapest *PathQuery `json:"cheapest,omitempty"`
+		ID       string       `json:"id,omitempty"` // client query ID, echoed in answer
+		Paths    *PathQuery   `json:"paths,omitempty"`
+		Cheapest *PathQuery   `json:"cheapest,omitempty"`
+		TopK     *TopKQuery   `json:"top_k,omitempty"`
+		Cycles   *CyclesQuery `json:"cycles,omitempty"`
+		SCC      *SCCQuery    `json:"scc,omitempty"`
+		Topo     *TopoQuery   `json:"topo,omitempty"`
+
+		Reachable     *NodeQuery          `json:"reachable,omitempty"`
+		Ancestors     *NodeQuery          `json:"ancestors,omitempty"`
+		Neighbourhood *NeighbourhoodQuery `json:"neighbourhood,omitempty"`
+
+		SSSP   *SSSPQuery   `json:"sssp,omitempty"`
+		Matrix *MatrixQuery `json:"matrix,omitempty"`
+
+		CriticalPath *CriticalPathQuery `json:"critical_path,omitempty"`
 	}
 
 	RequestQuery struct {
+		Version int    `json:"version,omitempty"` // protocol version, VersionLegacy if empty
+		Graph   string `json:"graph,omitempty"`   // target graph ID, default graph if empty
 		Queries []Query
 	}
 
 	PathResponse struct {
 		From  string      `json:"from"`
 		To    string      `json:"to"`
-		Paths interface{} `json:"paths,omitempty"` // [ [ "a", "b", "e" ], [ "a", "e" ] ]
-		Path  interface{} `json:"path,omitempty"`  // [ "a", "e" ] - false
+		Paths interface{} `json:"paths,omitempty"` // [ [ "a", "b", "e" ], [ "a", "e" ] ] or [ { "path": [ "a", "e" ], "cost": 42 } ] for top_k, []Path in VersionTyped
+		Path  interface{} `json:"path,omitempty"`  // [ "a", "e" ] or Path in VersionTyped - false
+
+		Truncated  bool `json:"truncated,omitempty"`   // paths query has more paths than limit
+		NextOffset int  `json:"next_offset,omitempty"` // offset of the next page if truncated
+	}
+
+	Path struct {
+		Nodes []string `json:"nodes"` // [ "a", "b", "e" ]
+		Edges []string `json:"edges"` // [ "a2", "b3" ]
+		Cost  float64  `json:"cost"`
+		Hops  int      `json:"hops"`
+		ElementAttributes
+	}
+
+	// ElementAttributes are attributes of nodes and edges by ID, elements without attributes are omitted
+	ElementAttributes struct {
+		NodeAttributes map[string]map[string]interface{} `json:"node_attributes,omitempty"` // {"a": {"capacity": 10}}
+		EdgeAttributes map[string]map[string]interface{} `json:"edge_attributes,omitempty"` // {"a2": {"owner": "x"}}
+	}
+
+	PathCost struct {
+		Path []string `json:"path"`
+		Cost float64  `json:"cost"`
+	}
+
+	// Cycle has ElementAttributes in VersionTyped only, as Path
+	Cycle struct {
+		Nodes []string `json:"nodes"` // [ "a", "e", "c", "a" ]
+		Edges []string `json:"edges"` // [ "a1", "e1", "c1" ]
+		ElementAttributes
+	}
+
+	CyclesResponse struct {
+		Cycles    []Cycle `json:"cycles"`
+		Truncated bool    `json:"truncated"` // more cycles than limit
+	}
+
+	SCCResponse struct {
+		// Components are strongly connected components in topological order, [ [ "a", "c", "e" ], [ "b" ] ]
+		Components [][]string `json:"components"`
+		// Edges are condensation DAG edges by component index, if condensation is requested
+		Edges []ComponentEdge `json:"edges,omitempty"`
+	}
+
+	ComponentEdge struct {
+		From int `json:"from"`
+		To   int `json:"to"`
+	}
+
+	NodesResponse struct {
+		Node  string   `json:"node"`
+		Nodes []string `json:"nodes"` // [ "b", "d" ]
+	}
+
+	NeighbourhoodResponse struct {
+		Node  string  `json:"node"`
+		Nodes []Reach `json:"nodes"` // ordered by cost, hops and node
+	}
+
+	// Reach is node with the least hops and the least cost from the query node, searched separately
+	// so the cheapest path may have more hops
+	Reach struct {
+		Node string  `json:"node"`
+		Hops int     `json:"hops"`
+		Cost float64 `json:"cost"`
+	}
+
+	// SSSPResponse is the shortest path tree, every reached node has its cost and predecessor but the origin
+	SSSPResponse struct {
+		Origin       string                 `json:"origin"`
+		Costs        map[string]float64     `json:"costs"`        // {"a": 0, "b": 10}
+		Predecessors map[string]Predecessor `json:"predecessors"` // {"b": {"node": "a", "edge": "a2"}}
+	}
+
+	Predecessor struct {
+		Node string `json:"node"`
+		Edge string `json:"edge"`
+	}
 
+	// MatrixResponse has Costs[i][j] from Origins[i] to Destinations[j], null if unreachable.
+	// Row of origin a negative cycle is reached from is null and Errors[i] is negative_cycle error,
+	// Errors is omitted if all rows are answered
+	MatrixResponse struct {
+		Origins      []string      `json:"origins"`
+		Destinations []string      `json:"destinations"`
+		Costs        [][]*float64  `json:"costs"`
+		Errors       []*QueryError `json:"errors,omitempty"`
 	}
 
+	// TopoResponse has nodes in topological order or cycle if the graph is not a DAG
+	TopoResponse struct {
+		Order []string `json:"order,omitempty"`
+		Cycle *Cycle   `json:"cycle,omitempty"`
+	}
+
+	// CriticalPathResponse is schedule of DAG where edge cost is duration or cycle if the graph is not a DAG
+	CriticalPathResponse struct {
+		Duration float64        `json:"duration"`
+		Path     interface{}    `json:"path,omitempty"`  // the longest path, [ "a", "e" ] or Path in VersionTyped
+		Nodes    []NodeSchedule `json:"nodes,omitempty"` // in topological order
+		Cycle    *Cycle         `json:"cycle,omitempty"`
+	}
+
+	NodeSchedule struct {
+		Node          string  `json:"node"`
+		EarliestStart float64 `json:"earliest_start"`
+		LatestStart   float64 `json:"latest_start"`
+		Slack         float64 `json:"slack"` // zero on critical path
+	}
+
+	QueryError struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+		Cycle   *Cycle `json:"cycle,omitempty"` // negative cycle of negative_cycle error
+	}
+
+	// QueryAnswer holds response of the query type, the same key as in Query.
+	// Error is set instead of responses if the query is invalid
+	QueryAnswer struct {
+		ID       string          `json:"id,omitempty"`
+		Paths    *PathResponse   `json:"paths,omitempty"`
+		Cheapest *PathResponse   `json:"cheapest,omitempty"`
+		TopK     *PathResponse   `json:"top_k,omitempty"`
+		Cycles   *CyclesResponse `json:"cycles,omitempty"`
+		SCC      *SCCResponse    `json:"scc,omitempty"`
+		Topo     *TopoResponse   `json:"topo,omitempty"`
+
+		Reachable     *NodesResponse         `json:"reachable,omitempty"`
+		Ancestors     *NodesResponse         `json:"ancestors,omitempty"`
+		Neighbourhood *NeighbourhoodResponse `json:"neighbourhood,omitempty"`
+
+		SSSP   *SSSPResponse   `json:"sssp,omitempty"`
+		Matrix *MatrixResponse `json:"matrix,omitempty"`
+
+		CriticalPath *CriticalPathResponse `json:"critical_path,omitempty"`
+
+		Error *QueryError `json:"error,omitempty"`
+	}
+
+	// Answer has answer for every query with the same index
 	Answer struct {
-		Answers []map[string]PathResponse `json:"answers"`
+		Version int           `json:"version,omitempty"`
+		Graph   string        `json:"graph,omitempty"`
+		Answers []QueryAnswer `json:"answers"`
+	}
+
+	// StreamAnswer is a single query answer written as soon as the query is evaluated,
+	// Index is the query index in request
+	StreamAnswer struct {
+		Graph string `json:"graph,omitempty"`
+		Index int    `json:"index"`
+		QueryAnswer
+	}
+
+	// ErrorAnswer answers request which can't be evaluated
+	ErrorAnswer struct {
+		Error QueryError `json:"error"`
 	}
 )
+
+func (e *QueryError) Error() string {
+	return e.Message
+}
@@ -0,0 +1,119 @@
+package entity
+
+import (
+	"context"
+	"slices"
+	"sort"
+)
+
+// GetTopKPaths returns up to k cheapest simple paths from start to end ordered by total cost.
+// Yen's algorithm: every next path deviates from an already found one at some spur node,
+// the spur part is searched by Dijkstra with the shared root and used continuations removed.
+// Paths over different parallel edges are different paths, only nodes and edges allowed by filter are traversed.
+func (g Graph) GetTopKPaths(ctx context.Context, start, end string, k int, filter Filter) ([]PathsCost, error) {
+	if k <= 0 {
+		return nil, nil
+	}
+
+	c := newCanceller(ctx)
+
+	path, err := g.shortestPath(c, filter, start, end, nil, nil)
+	if err != nil || path == nil {
+		return nil, err
+	}
+
+	var (
+		found      = []PathsCost{*path}
+		candidates = make([]PathsCost, 0)
+	)
+
+	for len(found) < k {
+		last := found[len(found)-1]
+
+		for i := 0; i < len(last.Path)-1; i++ {
+			var (
+				spur         = last.Path[i]
+				root         = last.Path[:i+1]
+				rootEdges    = last.Edges[:i]
+				removedNodes = make(map[string]bool, i)
+				removedEdges = make(map[edgeKey]bool)
+			)
+
+			// forbid continuations already taken by found paths sharing the same root
+			for _, p := range found {
+				if len(p.Path) > i+1 && slices.Equal(p.Path[:i+1], root) && slices.Equal(p.Edges[:i], rootEdges) {
+					removedEdges[edgeKey{from: p.Path[i], to: p.Path[i+1], id: p.Edges[i]}] = true
+				}
+			}
+
+			// root nodes except the spur must not be visited again to keep the path simple
+			for _, n := range root[:i] {
+				removedNodes[n] = true
+			}
+
+			spurPath, err := g.shortestPath(c, filter, spur, end, removedNodes, removedEdges)
+			if err != nil {
+				return nil, err
+			}
+			if spurPath == nil {
+				continue
+			}
+
+			candidate := PathsCost{
+				Path:      append(slices.Clone(root[:i]), spurPath.Path...),
+				Edges:     append(slices.Clone(rootEdges), spurPath.Edges...),
+				TotalCost: g.pathCost(root, rootEdges) + spurPath.TotalCost,
+			}
+
+			if !containsPath(found, candidate) && !containsPath(candidates, candidate) {
+				candidates = append(candidates, candidate)
+			}
+		}
+
+		if len(candidates) == 0 {
+			break
+		}
+
+		// take the cheapest candidate, shorter path wins on equal cost
+		sort.SliceStable(candidates, func(i, j int) bool {
+			if candidates[i].TotalCost == candidates[j].TotalCost {
+				return len(candidates[i].Path) < len(candidates[j].Path)
+			}
+			return candidates[i].TotalCost < candidates[j].TotalCost
+		})
+
+		found = append(found, candidates[0])
+		candidates = candidates[1:]
+	}
+
+	return found, nil
+}
+
+// pathCost sums costs of edges between consecutive nodes of the path.
+func (g Graph) pathCost(path, edges []string) float64 {
+	var total float64
+
+	for i := 0; i < len(path)-1; i++ {
+		cost, first := 0.0, true
+		for _, next := range g.AdjacencyList[path[i]] {
+			if next.Next == path[i+1] && next.ID == edges[i] && (first || next.Cost < cost) {
+				cost, first = next.Cost, false
+			}
+		}
+
+		total += cost
+	}
+
+	return total
+}
+
+// containsPath reports paths has the same nodes over the same edges
+func containsPath(paths []PathsCost, path PathsCost) bool {
+	for _, p := range paths {
+		if slices.Equal(p.Path, path.Path) && slices.Equal(p.Edges, path.Edges) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,58 @@
+package entity
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+)
+
+func TestGetTopKPathsMatchesSimplePaths(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	for run := 0; run < 200; run++ {
+		graph := randomGraph(rnd, 6, 12)
+		all := simplePaths(graph, "0", "5")
+
+		for _, k := range []int{1, 3, len(all) + 1} {
+			paths, err := graph.GetTopKPaths(context.Background(), "0", "5", k, Filter{})
+			if err != nil {
+				t.Fatalf("run %d: unexpected error %v", run, err)
+			}
+
+			if expected := min(k, len(all)); len(paths) != expected {
+				t.Fatalf("run %d, k %d: expected %d paths, got %d", run, k, expected, len(paths))
+			}
+
+			for i, p := range paths {
+				if p.TotalCost != all[i].TotalCost {
+					t.Errorf("run %d, k %d: path %d expected cost %v, got %v", run, k, i, all[i].TotalCost, p.TotalCost)
+				}
+
+				if !containsPath(all, p) || graph.pathCost(p.Path, p.Edges) != p.TotalCost {
+					t.Errorf("run %d, k %d: path %d %v is not a simple path of the graph", run, k, i, p)
+				}
+
+				if containsPath(paths[:i], p) {
+					t.Errorf("run %d, k %d: path %d %v is returned twice", run, k, i, p)
+				}
+			}
+		}
+	}
+}
+
+func TestGetTopKPathsParallelEdges(t *testing.T) {
+	graph := &Graph{AdjacencyList: map[string][]Edge{
+		"a": {{ID: "a1", Next: "b", Cost: 2}, {ID: "a2", Next: "b", Cost: 1}},
+		"b": {{ID: "b1", Next: "c", Cost: 1}},
+		"c": nil,
+	}}
+
+	paths, err := graph.GetTopKPaths(context.Background(), "a", "c", 3, Filter{})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if len(paths) != 2 || paths[0].Edges[0] != "a2" || paths[1].Edges[0] != "a1" {
+		t.Errorf("expected paths over a2 then a1, got %+v", paths)
+	}
+}
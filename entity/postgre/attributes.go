@@ -0,0 +1,59 @@
+package postgre
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Attributes are typed key/value pairs stored as JSONB, values are string, float64 or bool
+type Attributes map[string]interface{}
+
+// Value stores nil attributes as empty JSON object
+func (a Attributes) Value() (driver.Value, error) {
+	if a == nil {
+		return []byte("{}"), nil
+	}
+
+	return json.Marshal(a)
+}
+
+// Scan loads attributes, empty object is loaded as nil
+func (a *Attributes) Scan(src interface{}) error {
+	var data []byte
+
+	switch v := src.(type) {
+	case nil:
+		*a = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported attributes type %T", src)
+	}
+
+	var values map[string]interface{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("failed to unmarshal attributes: %w", err)
+	}
+
+	if len(values) == 0 {
+		values = nil
+	}
+	*a = values
+
+	return nil
+}
+
+// Equal reports a and b have the same values, nil and empty attributes are equal.
+// Values are compared deeply, JSONB stored by hand may hold arrays and objects.
+func (a Attributes) Equal(b Attributes) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+
+	return reflect.DeepEqual(a, b)
+}
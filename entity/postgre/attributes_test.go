@@ -0,0 +1,39 @@
+package postgre
+
+import "testing"
+
+func TestAttributesEqual(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     string // as scanned from JSONB
+		expected bool
+	}{
+		{name: "empty", a: `{}`, b: `{}`, expected: true},
+		{name: "scalars", a: `{"capacity": 10, "mode": "rail", "open": true}`, b: `{"open": true, "mode": "rail", "capacity": 10}`, expected: true},
+		{name: "other scalar", a: `{"capacity": 10}`, b: `{"capacity": 11}`, expected: false},
+		{name: "other key", a: `{"capacity": 10}`, b: `{"size": 10}`, expected: false},
+		{name: "arrays", a: `{"modes": ["rail", "road"]}`, b: `{"modes": ["rail", "road"]}`, expected: true},
+		{name: "other arrays", a: `{"modes": ["rail", "road"]}`, b: `{"modes": ["road", "rail"]}`, expected: false},
+		{name: "objects", a: `{"owner": {"id": 1}}`, b: `{"owner": {"id": 1}}`, expected: true},
+		{name: "other objects", a: `{"owner": {"id": 1}}`, b: `{"owner": {"id": 2}}`, expected: false},
+		{name: "array and object", a: `{"owner": [1]}`, b: `{"owner": {"id": 1}}`, expected: false},
+	}
+
+	for _, tt := range tests {
+		var a, b Attributes
+		if err := a.Scan(tt.a); err != nil {
+			t.Fatalf("%s: unexpected error %v", tt.name, err)
+		}
+		if err := b.Scan([]byte(tt.b)); err != nil {
+			t.Fatalf("%s: unexpected error %v", tt.name, err)
+		}
+
+		if got := a.Equal(b); got != tt.expected {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, got)
+		}
+	}
+
+	if !Attributes(nil).Equal(Attributes{}) {
+		t.Errorf("expected nil and empty attributes to be equal")
+	}
+}
@@ -0,0 +1,118 @@
+package postgre
+
+import (
+	"fmt"
+	"math"
+)
+
+// costScale is the number of decimals edges.cost column keeps, NUMERIC(10,2)
+const costScale = 100
+
+// GraphChanges is a difference between stored graph and a new version of it
+type GraphChanges struct {
+	Created     bool
+	NameChanged bool
+
+	AddedNodes   []Node
+	RemovedNodes []Node
+	ChangedNodes []Node
+
+	AddedEdges   []Edge
+	RemovedEdges []Edge
+	ChangedEdges []Edge
+}
+
+// Diff compares stored graph with graph, stored is nil when graph is not saved yet.
+// Changed nodes and edges are taken from graph.
+func Diff(stored, graph *Graph) *GraphChanges {
+	if stored == nil {
+		return &GraphChanges{
+			Created:    true,
+			AddedNodes: graph.Nodes,
+			AddedEdges: graph.Edges,
+		}
+	}
+
+	changes := GraphChanges{NameChanged: stored.Name != graph.Name}
+
+	storedNodes := make(map[string]Node, len(stored.Nodes))
+	for _, n := range stored.Nodes {
+		storedNodes[n.ID] = n
+	}
+
+	for _, n := range graph.Nodes {
+		old, ok := storedNodes[n.ID]
+		switch {
+		case !ok:
+			changes.AddedNodes = append(changes.AddedNodes, n)
+		case !old.equal(n):
+			changes.ChangedNodes = append(changes.ChangedNodes, n)
+		}
+		delete(storedNodes, n.ID)
+	}
+
+	for _, n := range stored.Nodes {
+		if _, ok := storedNodes[n.ID]; ok {
+			changes.RemovedNodes = append(changes.RemovedNodes, n)
+		}
+	}
+
+	storedEdges := make(map[string]Edge, len(stored.Edges))
+	for _, e := range stored.Edges {
+		storedEdges[e.ID] = e
+	}
+
+	for _, e := range graph.Edges {
+		old, ok := storedEdges[e.ID]
+		switch {
+		case !ok:
+			changes.AddedEdges = append(changes.AddedEdges, e)
+		case !old.equal(e):
+			changes.ChangedEdges = append(changes.ChangedEdges, e)
+		}
+		delete(storedEdges, e.ID)
+	}
+
+	for _, e := range stored.Edges {
+		if _, ok := storedEdges[e.ID]; ok {
+			changes.RemovedEdges = append(changes.RemovedEdges, e)
+		}
+	}
+
+	return &changes
+}
+
+func (n Node) equal(o Node) bool {
+	return n.ID == o.ID && n.Name == o.Name && n.GraphID == o.GraphID && n.Attributes.Equal(o.Attributes)
+}
+
+func (e Edge) equal(o Edge) bool {
+	return e.ID == o.ID && e.Name == o.Name && e.PreviousNode == o.PreviousNode && e.NextNode == o.NextNode &&
+		costEqual(e.Cost, o.Cost) && e.GraphID == o.GraphID && e.Bidirectional == o.Bidirectional && e.Attributes.Equal(o.Attributes)
+}
+
+// costEqual compares costs rounded as stored, otherwise cost with more decimals changes on every upsert
+func costEqual(a, b float64) bool {
+	return math.Round(a*costScale) == math.Round(b*costScale)
+}
+
+// Empty reports graph is stored as is
+func (c *GraphChanges) Empty() bool {
+	return !c.Created && !c.NameChanged &&
+		len(c.AddedNodes) == 0 && len(c.RemovedNodes) == 0 && len(c.ChangedNodes) == 0 &&
+		len(c.AddedEdges) == 0 && len(c.RemovedEdges) == 0 && len(c.ChangedEdges) == 0
+}
+
+func (c *GraphChanges) String() string {
+	status := "updated"
+	switch {
+	case c.Created:
+		status = "created"
+	case c.Empty():
+		status = "unchanged"
+	}
+
+	return fmt.Sprintf("graph %s, name changed: %t, nodes: +%d -%d ~%d, edges: +%d -%d ~%d", status, c.NameChanged,
+		len(c.AddedNodes), len(c.RemovedNodes), len(c.ChangedNodes),
+		len(c.AddedEdges), len(c.RemovedEdges), len(c.ChangedEdges))
+}
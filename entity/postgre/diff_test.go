@@ -0,0 +1,132 @@
+package postgre
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	var (
+		a = Node{ID: "a", Name: "A", GraphID: "g0"}
+		b = Node{ID: "b", Name: "B", GraphID: "g0"}
+		c = Node{ID: "c", Name: "C", GraphID: "g0"}
+
+		ab = Edge{ID: "ab", PreviousNode: "a", NextNode: "b", Cost: 1.5, GraphID: "g0"}
+		bc = Edge{ID: "bc", PreviousNode: "b", NextNode: "c", Cost: 2, GraphID: "g0"}
+	)
+
+	stored := &Graph{ID: "g0", Name: "graph", Nodes: []Node{a, b}, Edges: []Edge{ab}}
+
+	renamedA := a
+	renamedA.Name = "A2"
+
+	attributedA := a
+	attributedA.Attributes = Attributes{"capacity": 10.0}
+
+	emptyAttributesA := a
+	emptyAttributesA.Attributes = Attributes{}
+
+	movedAB := ab
+	movedAB.NextNode = "c"
+
+	costlierAB := ab
+	costlierAB.Cost = 1.6
+
+	preciseAB := ab
+	preciseAB.Cost = 1.504 // stored as 1.50
+
+	bidirectionalAB := ab
+	bidirectionalAB.Bidirectional = true
+
+	tests := []struct {
+		name     string
+		stored   *Graph
+		graph    *Graph
+		expected GraphChanges
+	}{
+		{
+			name:     "created",
+			graph:    stored,
+			expected: GraphChanges{Created: true, AddedNodes: []Node{a, b}, AddedEdges: []Edge{ab}},
+		},
+		{
+			name:     "unchanged",
+			stored:   stored,
+			graph:    &Graph{ID: "g0", Name: "graph", Nodes: []Node{b, a}, Edges: []Edge{ab}},
+			expected: GraphChanges{},
+		},
+		{
+			name:     "renamed graph",
+			stored:   stored,
+			graph:    &Graph{ID: "g0", Name: "graph2", Nodes: []Node{a, b}, Edges: []Edge{ab}},
+			expected: GraphChanges{NameChanged: true},
+		},
+		{
+			name:     "added node and edge",
+			stored:   stored,
+			graph:    &Graph{ID: "g0", Name: "graph", Nodes: []Node{a, b, c}, Edges: []Edge{ab, bc}},
+			expected: GraphChanges{AddedNodes: []Node{c}, AddedEdges: []Edge{bc}},
+		},
+		{
+			name:     "removed node and edge",
+			stored:   stored,
+			graph:    &Graph{ID: "g0", Name: "graph", Nodes: []Node{a}},
+			expected: GraphChanges{RemovedNodes: []Node{b}, RemovedEdges: []Edge{ab}},
+		},
+		{
+			name:     "changed node name",
+			stored:   stored,
+			graph:    &Graph{ID: "g0", Name: "graph", Nodes: []Node{renamedA, b}, Edges: []Edge{ab}},
+			expected: GraphChanges{ChangedNodes: []Node{renamedA}},
+		},
+		{
+			name:     "changed node attributes",
+			stored:   stored,
+			graph:    &Graph{ID: "g0", Name: "graph", Nodes: []Node{attributedA, b}, Edges: []Edge{ab}},
+			expected: GraphChanges{ChangedNodes: []Node{attributedA}},
+		},
+		{
+			name:     "nil and empty attributes are equal",
+			stored:   stored,
+			graph:    &Graph{ID: "g0", Name: "graph", Nodes: []Node{emptyAttributesA, b}, Edges: []Edge{ab}},
+			expected: GraphChanges{},
+		},
+		{
+			name:     "moved edge",
+			stored:   stored,
+			graph:    &Graph{ID: "g0", Name: "graph", Nodes: []Node{a, b, c}, Edges: []Edge{movedAB}},
+			expected: GraphChanges{AddedNodes: []Node{c}, ChangedEdges: []Edge{movedAB}},
+		},
+		{
+			name:     "changed edge cost",
+			stored:   stored,
+			graph:    &Graph{ID: "g0", Name: "graph", Nodes: []Node{a, b}, Edges: []Edge{costlierAB}},
+			expected: GraphChanges{ChangedEdges: []Edge{costlierAB}},
+		},
+		{
+			name:     "edge cost equal at stored scale",
+			stored:   stored,
+			graph:    &Graph{ID: "g0", Name: "graph", Nodes: []Node{a, b}, Edges: []Edge{preciseAB}},
+			expected: GraphChanges{},
+		},
+		{
+			name:     "changed edge direction",
+			stored:   stored,
+			graph:    &Graph{ID: "g0", Name: "graph", Nodes: []Node{a, b}, Edges: []Edge{bidirectionalAB}},
+			expected: GraphChanges{ChangedEdges: []Edge{bidirectionalAB}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes := Diff(tt.stored, tt.graph)
+			if !reflect.DeepEqual(*changes, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, *changes)
+			}
+
+			if empty := reflect.DeepEqual(tt.expected, GraphChanges{}); changes.Empty() != empty {
+				t.Errorf("expected Empty %t, got %t", empty, changes.Empty())
+			}
+		})
+	}
+}
@@ -13,16 +13,22 @@ type (
 	}
 
 	Node struct {
-		ID      string `db:"id"`
-		Name    string `db:"name"`
-		GraphID string `db:"graph_id"`
+		ID         string     `db:"id"`
+		Name       string     `db:"name"`
+		GraphID    string     `db:"graph_id"`
+		Attributes Attributes `db:"attributes"`
 	}
 
 	Edge struct {
 		ID           string  `db:"id"`
+		Name         string  `db:"name"`
 		PreviousNode string  `db:"previous_node"`
 		NextNode     string  `db:"next_node"`
 		Cost         float64 `db:"cost"`
+		GraphID      string  `db:"graph_id"`
+		// Bidirectional edge goes from NextNode to PreviousNode as well
+		Bidirectional bool       `db:"bidirectional"`
+		Attributes    Attributes `db:"attributes"`
 	}
 )
 
@@ -37,7 +43,7 @@ func NewGraph(graph xml.Graph) *Graph {
 	}
 
 	for _, edge := range graph.Edges.Edges {
-		edges = append(edges, makeEdge(edge))
+		edges = append(edges, makeEdge(edge, graph.ID, !graph.IsDirected(edge)))
 	}
 
 	return &Graph{
@@ -50,17 +56,22 @@ func NewGraph(graph xml.Graph) *Graph {
 
 func makeNode(node xml.Node, graphID string) Node {
 	return Node{
-		ID:      node.ID,
-		Name:    node.Name,
-		GraphID: graphID,
+		ID:         node.ID,
+		Name:       node.Name,
+		GraphID:    graphID,
+		Attributes: node.Attributes.Map(),
 	}
 }
 
-func makeEdge(edge xml.Edge) Edge {
+func makeEdge(edge xml.Edge, graphID string, bidirectional bool) Edge {
 	return Edge{
-		ID:           edge.ID,
-		PreviousNode: edge.From,
-		NextNode:     edge.To,
-		Cost:         edge.Cost,
+		ID:            edge.ID,
+		Name:          edge.Name,
+		PreviousNode:  edge.From,
+		NextNode:      edge.To,
+		Cost:          edge.Cost,
+		GraphID:       graphID,
+		Bidirectional: bidirectional,
+		Attributes:    edge.Attributes.Map(),
 	}
 }
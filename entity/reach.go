@@ -0,0 +1,223 @@
+package entity
+
+import (
+	"container/heap"
+	"context"
+	"sort"
+)
+
+type (
+	// Reach is a node reached from the origin with the least hops and the least cost,
+	// both are searched separately, so the cheapest path may have more hops.
+	Reach struct {
+		Node string
+		Hops int
+		Cost float64
+	}
+
+	// NeighbourhoodLimit bounds Neighbourhood search, zero fields are not limited
+	NeighbourhoodLimit struct {
+		MaxHops int
+		MaxCost float64
+		Reverse bool // follow edges backwards, i.e. search nodes leading to the origin
+	}
+)
+
+// Reachable returns IDs of nodes reached from node in ID order, node itself is not included.
+func (g Graph) Reachable(ctx context.Context, node string, filter Filter) ([]string, error) {
+	hops, err := g.hopDistances(newCanceller(ctx), g.AdjacencyList, node, 0, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return sortedKeys(hops, node), nil
+}
+
+// Ancestors returns IDs of nodes leading to node in ID order, node itself is not included.
+func (g Graph) Ancestors(ctx context.Context, node string, filter Filter) ([]string, error) {
+	hops, err := g.hopDistances(newCanceller(ctx), g.reverseList(), node, 0, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return sortedKeys(hops, node), nil
+}
+
+// Neighbourhood returns nodes within limit.MaxHops hops and limit.MaxCost cost of node,
+// ordered by cost, hops and ID. Node itself is not included.
+// Hops are counted by BFS and costs by Dijkstra's search, the limits are intersected: node is returned
+// if its least hops and its least cost are both within limits, they may come from different paths.
+func (g Graph) Neighbourhood(ctx context.Context, node string, limit NeighbourhoodLimit, filter Filter) ([]Reach, error) {
+	var (
+		c         = newCanceller(ctx)
+		adjacency = g.AdjacencyList
+	)
+
+	if limit.Reverse {
+		adjacency = g.reverseList()
+	}
+
+	hops, err := g.hopDistances(c, adjacency, node, limit.MaxHops, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	costs, _, err := g.shortestTree(c, adjacency, node, limit.MaxCost, nil, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]Reach, 0, len(hops))
+	for n, h := range hops {
+		if cost, ok := costs[n]; ok && n != node {
+			res = append(res, Reach{Node: n, Hops: h, Cost: cost})
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Cost != res[j].Cost {
+			return res[i].Cost < res[j].Cost
+		}
+		if res[i].Hops != res[j].Hops {
+			return res[i].Hops < res[j].Hops
+		}
+		return res[i].Node < res[j].Node
+	})
+
+	return res, nil
+}
+
+// hopDistances is BFS from origin over adjacency, it returns hops to every node reached within maxHops
+func (g Graph) hopDistances(c *canceller, adjacency map[string][]Edge, origin string, maxHops int, filter Filter) (map[string]int, error) {
+	if _, ok := adjacency[origin]; !ok || !filter.allowNode(g, origin) {
+		return map[string]int{}, nil
+	}
+
+	var (
+		hops  = map[string]int{origin: 0}
+		queue = []string{origin}
+	)
+
+	for len(queue) > 0 {
+		if err := c.err(); err != nil {
+			return nil, err
+		}
+
+		n := queue[0]
+		queue = queue[1:]
+
+		if maxHops > 0 && hops[n] >= maxHops {
+			continue
+		}
+
+		for _, e := range adjacency[n] {
+			if _, ok := hops[e.Next]; ok || !filter.allowEdge(e) || !filter.allowNode(g, e.Next) {
+				continue
+			}
+
+			hops[e.Next] = hops[n] + 1
+			queue = append(queue, e.Next)
+		}
+	}
+
+	return hops, nil
+}
+
+// shortestTree is Dijkstra's search from origin over adjacency, it returns the least cost of every node within maxCost
+// and the edge it is reached by. Search stops when all targets are settled, nil targets means every node.
+// Graph with negative costs is searched by Bellman-Ford, it fails with NegativeCycleError when negative cycle is reached.
+func (g Graph) shortestTree(c *canceller, adjacency map[string][]Edge, origin string, maxCost float64,
+	targets map[string]bool, filter Filter) (map[string]float64, map[string]step, error) {
+	if g.NegativeCosts {
+		return g.shortestTreeNegative(c, adjacency, origin, maxCost, filter)
+	}
+
+	if _, ok := adjacency[origin]; !ok || !filter.allowNode(g, origin) {
+		return map[string]float64{}, map[string]step{}, nil
+	}
+
+	var (
+		dist     = map[string]float64{origin: 0}
+		previous = make(map[string]step)
+		settled  = make(map[string]bool)
+		left     = len(targets)
+		queue    = &priorityQueue{{node: origin, cost: 0}}
+	)
+
+	for queue.Len() > 0 {
+		if err := c.err(); err != nil {
+			return nil, nil, err
+		}
+
+		item := heap.Pop(queue).(queueItem)
+		if settled[item.node] {
+			continue
+		}
+		settled[item.node] = true
+
+		if targets[item.node] {
+			if left--; left == 0 {
+				break
+			}
+		}
+
+		for _, e := range adjacency[item.node] {
+			if settled[e.Next] || !filter.allowEdge(e) || !filter.allowNode(g, e.Next) {
+				continue
+			}
+
+			cost := item.cost + e.Cost
+			if maxCost > 0 && cost > maxCost {
+				continue
+			}
+
+			if d, ok := dist[e.Next]; !ok || cost < d {
+				dist[e.Next] = cost
+				previous[e.Next] = step{node: item.node, edge: e.ID}
+				heap.Push(queue, queueItem{node: e.Next, cost: cost})
+			}
+		}
+	}
+
+	return dist, previous, nil
+}
+
+// reverseList returns the reverse adjacency index, it is built when the graph is made by hand
+func (g Graph) reverseList() map[string][]Edge {
+	if g.ReverseList != nil {
+		return g.ReverseList
+	}
+
+	return reverseAdjacency(g.AdjacencyList)
+}
+
+// reverseAdjacency returns edges coming into every node, Edge.Next of reverse edge is the node it comes from
+func reverseAdjacency(adjacency map[string][]Edge) map[string][]Edge {
+	reverse := make(map[string][]Edge, len(adjacency))
+	for n := range adjacency {
+		reverse[n] = nil
+	}
+
+	for n, edges := range adjacency {
+		for _, e := range edges {
+			prev := e
+			prev.Next = n
+			reverse[e.Next] = append(reverse[e.Next], prev)
+		}
+	}
+
+	return reverse
+}
+
+// sortedKeys returns nodes in ID order except skip
+func sortedKeys(nodes map[string]int, skip string) []string {
+	keys := make([]string, 0, len(nodes))
+	for n := range nodes {
+		if n != skip {
+			keys = append(keys, n)
+		}
+	}
+	sort.Strings(keys)
+
+	return keys
+}
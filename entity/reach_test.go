@@ -0,0 +1,143 @@
+package entity
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// reachGraph has the cheap path a b c d over more hops than the direct edge a d
+func reachGraph() *Graph {
+	g := &Graph{
+		AdjacencyList: map[string][]Edge{
+			"a": {{ID: "ab", Next: "b", Cost: 1}, {ID: "ad", Next: "d", Cost: 10}},
+			"b": {{ID: "bc", Next: "c", Cost: 1}},
+			"c": {{ID: "cd", Next: "d", Cost: 1}},
+			"d": {{ID: "de", Next: "e", Cost: 1}},
+			"e": nil,
+			"x": {{ID: "xa", Next: "a", Cost: 1}},
+			"f": nil,
+		},
+		Nodes: map[string]Node{
+			"b": {Attributes: map[string]interface{}{"closed": true}},
+		},
+	}
+	g.ReverseList = reverseAdjacency(g.AdjacencyList)
+
+	return g
+}
+
+func TestReachable(t *testing.T) {
+	g := reachGraph()
+	closed := Filter{ExcludeNodes: []Predicate{{Attribute: "closed", Value: true}}}
+
+	tests := []struct {
+		name      string
+		ancestors bool
+		node      string
+		filter    Filter
+		expected  []string
+	}{
+		{name: "reachable", node: "a", expected: []string{"b", "c", "d", "e"}},
+		{name: "reachable from sink", node: "e", expected: []string{}},
+		{name: "reachable from isolated", node: "f", expected: []string{}},
+		{name: "reachable unknown", node: "z", expected: []string{}},
+		{name: "reachable filtered", node: "a", filter: closed, expected: []string{"d", "e"}},
+		{name: "reachable without edge", node: "a", filter: Filter{ExcludeEdges: []Predicate{{IDs: []string{"ad"}}}}, expected: []string{"b", "c", "d", "e"}},
+		{name: "reachable from excluded", node: "b", filter: closed, expected: []string{}},
+		{name: "ancestors", ancestors: true, node: "d", expected: []string{"a", "b", "c", "x"}},
+		{name: "ancestors of source", ancestors: true, node: "x", expected: []string{}},
+		{name: "ancestors filtered", ancestors: true, node: "d", filter: closed, expected: []string{"a", "c", "x"}},
+		{name: "ancestors without edge", ancestors: true, node: "d", filter: Filter{ExcludeEdges: []Predicate{{IDs: []string{"ad"}}}}, expected: []string{"a", "b", "c", "x"}},
+		{name: "ancestors filtered without edge", ancestors: true, node: "d", filter: Filter{ExcludeNodes: closed.ExcludeNodes, ExcludeEdges: []Predicate{{IDs: []string{"ad"}}}}, expected: []string{"c"}},
+	}
+
+	for _, tt := range tests {
+		search := g.Reachable
+		if tt.ancestors {
+			search = g.Ancestors
+		}
+
+		got, err := search(context.Background(), tt.node, tt.filter)
+		if err != nil {
+			t.Fatalf("%s: unexpected error %v", tt.name, err)
+		}
+
+		if !reflect.DeepEqual(tt.expected, got) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestNeighbourhood(t *testing.T) {
+	g := reachGraph()
+
+	tests := []struct {
+		name     string
+		node     string
+		limit    NeighbourhoodLimit
+		filter   Filter
+		expected []Reach
+	}{
+		{
+			name:     "not limited",
+			node:     "a",
+			expected: []Reach{{Node: "b", Hops: 1, Cost: 1}, {Node: "c", Hops: 2, Cost: 2}, {Node: "d", Hops: 1, Cost: 3}, {Node: "e", Hops: 2, Cost: 4}},
+		},
+		{
+			// d has the least hops over a d and the least cost over a b c d,
+			// c and e are within max cost only over more than max hops edges
+			name:     "limits intersected",
+			node:     "a",
+			limit:    NeighbourhoodLimit{MaxHops: 1, MaxCost: 5},
+			expected: []Reach{{Node: "b", Hops: 1, Cost: 1}, {Node: "d", Hops: 1, Cost: 3}},
+		},
+		{
+			name:     "max cost",
+			node:     "a",
+			limit:    NeighbourhoodLimit{MaxCost: 2},
+			expected: []Reach{{Node: "b", Hops: 1, Cost: 1}, {Node: "c", Hops: 2, Cost: 2}},
+		},
+		{
+			name:     "filtered",
+			node:     "a",
+			filter:   Filter{ExcludeNodes: []Predicate{{Attribute: "closed", Op: OpExists}}},
+			expected: []Reach{{Node: "d", Hops: 1, Cost: 10}, {Node: "e", Hops: 2, Cost: 11}},
+		},
+		{
+			name:     "reverse",
+			node:     "d",
+			limit:    NeighbourhoodLimit{Reverse: true, MaxHops: 2},
+			expected: []Reach{{Node: "c", Hops: 1, Cost: 1}, {Node: "b", Hops: 2, Cost: 2}, {Node: "a", Hops: 1, Cost: 3}, {Node: "x", Hops: 2, Cost: 4}},
+		},
+		{
+			name:     "reverse limits intersected",
+			node:     "d",
+			limit:    NeighbourhoodLimit{Reverse: true, MaxHops: 1, MaxCost: 2},
+			expected: []Reach{{Node: "c", Hops: 1, Cost: 1}},
+		},
+		{
+			name:     "reverse filtered",
+			node:     "d",
+			limit:    NeighbourhoodLimit{Reverse: true},
+			filter:   Filter{ExcludeEdges: []Predicate{{IDs: []string{"bc"}}}},
+			expected: []Reach{{Node: "c", Hops: 1, Cost: 1}, {Node: "a", Hops: 1, Cost: 10}, {Node: "x", Hops: 2, Cost: 11}},
+		},
+		{
+			name:     "unknown",
+			node:     "z",
+			expected: []Reach{},
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := g.Neighbourhood(context.Background(), tt.node, tt.limit, tt.filter)
+		if err != nil {
+			t.Fatalf("%s: unexpected error %v", tt.name, err)
+		}
+
+		if !reflect.DeepEqual(tt.expected, got) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, got)
+		}
+	}
+}
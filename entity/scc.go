@@ -0,0 +1,153 @@
+package entity
+
+import (
+	"context"
+	"sort"
+)
+
+// Condensation is the DAG of strongly connected components, every component is collapsed into one vertex.
+type Condensation struct {
+	// Components are node IDs of every component, components are in topological order and nodes are sorted
+	Components [][]string
+	// Component is the component index of node ID
+	Component map[string]int
+	// Edges[i] are sorted indexes of components reached from component i by an edge
+	Edges [][]int
+}
+
+// tarjanFrame is DFS stack frame, edge is the index of the next edge of node to follow
+type tarjanFrame struct {
+	node string
+	edge int
+}
+
+// StronglyConnectedComponents returns node sets which are mutually reachable, every node is in one component.
+// Components are in topological order of the condensation, nodes of component are sorted.
+// Tarjan's algorithm with explicit DFS stack, so deep graphs don't overflow goroutine stack.
+func (g Graph) StronglyConnectedComponents(ctx context.Context) ([][]string, error) {
+	var (
+		c          = newCanceller(ctx)
+		index      = make(map[string]int, len(g.AdjacencyList))
+		low        = make(map[string]int, len(g.AdjacencyList))
+		onStack    = make(map[string]bool)
+		stack      = make([]string, 0)
+		components = make([][]string, 0)
+	)
+
+	visit := func(n string) {
+		index[n] = len(index)
+		low[n] = index[n]
+		stack = append(stack, n)
+		onStack[n] = true
+	}
+
+	for _, root := range g.sortedNodes() {
+		if _, ok := index[root]; ok {
+			continue
+		}
+
+		visit(root)
+		frames := []tarjanFrame{{node: root}}
+
+		for len(frames) > 0 {
+			if err := c.err(); err != nil {
+				return nil, err
+			}
+
+			f := &frames[len(frames)-1]
+			if edges := g.AdjacencyList[f.node]; f.edge < len(edges) {
+				next := edges[f.edge].Next
+				f.edge++
+
+				if _, ok := index[next]; !ok {
+					visit(next)
+					frames = append(frames, tarjanFrame{node: next})
+				} else if onStack[next] {
+					low[f.node] = min(low[f.node], index[next])
+				}
+
+				continue
+			}
+
+			// all edges followed, node is done
+			n := f.node
+			frames = frames[:len(frames)-1]
+			if len(frames) > 0 {
+				parent := frames[len(frames)-1].node
+				low[parent] = min(low[parent], low[n])
+			}
+
+			// n is the root of component, its nodes are on the stack above it
+			if low[n] == index[n] {
+				var component []string
+				for {
+					top := stack[len(stack)-1]
+					stack = stack[:len(stack)-1]
+					onStack[top] = false
+					component = append(component, top)
+
+					if top == n {
+						break
+					}
+				}
+
+				sort.Strings(component)
+				components = append(components, component)
+			}
+		}
+	}
+
+	// Tarjan's algorithm finds components in reverse topological order
+	for i, j := 0, len(components)-1; i < j; i, j = i+1, j-1 {
+		components[i], components[j] = components[j], components[i]
+	}
+
+	return components, nil
+}
+
+// Condense returns condensation DAG of the graph.
+func (g Graph) Condense(ctx context.Context) (*Condensation, error) {
+	components, err := g.StronglyConnectedComponents(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cond := Condensation{
+		Components: components,
+		Component:  make(map[string]int, len(g.AdjacencyList)),
+		Edges:      make([][]int, len(components)),
+	}
+
+	for i, component := range components {
+		for _, n := range component {
+			cond.Component[n] = i
+		}
+	}
+
+	for i, component := range components {
+		seen := make(map[int]bool)
+		for _, n := range component {
+			for _, e := range g.AdjacencyList[n] {
+				if j, ok := cond.Component[e.Next]; ok && j != i && !seen[j] {
+					seen[j] = true
+					cond.Edges[i] = append(cond.Edges[i], j)
+				}
+			}
+		}
+
+		sort.Ints(cond.Edges[i])
+	}
+
+	return &cond, nil
+}
+
+// sortedNodes returns node IDs in ID order for stable search results
+func (g Graph) sortedNodes() []string {
+	nodes := make([]string, 0, len(g.AdjacencyList))
+	for n := range g.AdjacencyList {
+		nodes = append(nodes, n)
+	}
+	sort.Strings(nodes)
+
+	return nodes
+}
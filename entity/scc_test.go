@@ -0,0 +1,91 @@
+package entity
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// reachableFrom returns nodes reached from start including itself
+func reachableFrom(g *Graph, start string) map[string]bool {
+	return bfs(start, func(n string, visit func(string)) {
+		for _, e := range g.AdjacencyList[n] {
+			visit(e.Next)
+		}
+	})
+}
+
+func TestStronglyConnectedComponentsMatchMutualReachability(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	for run := 0; run < 200; run++ {
+		graph := randomGraph(rnd, 8, 10)
+
+		cond, err := graph.Condense(context.Background())
+		if err != nil {
+			t.Fatalf("run %d: unexpected error %v", run, err)
+		}
+
+		reach := make(map[string]map[string]bool, len(graph.AdjacencyList))
+		for n := range graph.AdjacencyList {
+			reach[n] = reachableFrom(graph, n)
+		}
+
+		seen := 0
+		for i, component := range cond.Components {
+			seen += len(component)
+
+			if !sort.StringsAreSorted(component) {
+				t.Errorf("run %d: component %v is not sorted", run, component)
+			}
+
+			for _, n := range component {
+				if cond.Component[n] != i {
+					t.Errorf("run %d: node %s of component %d is mapped to %d", run, n, i, cond.Component[n])
+				}
+			}
+		}
+
+		if seen != len(graph.AdjacencyList) {
+			t.Fatalf("run %d: expected %d nodes in components, got %d", run, len(graph.AdjacencyList), seen)
+		}
+
+		for n := range graph.AdjacencyList {
+			for m := range graph.AdjacencyList {
+				mutual := reach[n][m] && reach[m][n]
+				if same := cond.Component[n] == cond.Component[m]; same != mutual {
+					t.Errorf("run %d: nodes %s and %s mutually reachable %t, same component %t", run, n, m, mutual, same)
+				}
+			}
+		}
+
+		// condensation edges go forward in components order and match graph edges between components
+		expected := make(map[[2]int]bool)
+		for n, edges := range graph.AdjacencyList {
+			for _, e := range edges {
+				if from, to := cond.Component[n], cond.Component[e.Next]; from != to {
+					expected[[2]int{from, to}] = true
+				}
+			}
+		}
+
+		got := 0
+		for from, tos := range cond.Edges {
+			if !sort.IntsAreSorted(tos) {
+				t.Errorf("run %d: edges %v of component %d are not sorted", run, tos, from)
+			}
+
+			for _, to := range tos {
+				got++
+				if to <= from || !expected[[2]int{from, to}] {
+					t.Errorf("run %d: unexpected condensation edge %d -> %d", run, from, to)
+				}
+			}
+		}
+
+		if got != len(expected) {
+			t.Errorf("run %d: expected %d condensation edges, got %d", run, len(expected), got)
+		}
+	}
+}
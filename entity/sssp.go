@@ -0,0 +1,111 @@
+package entity
+
+import (
+	"context"
+	"errors"
+	"math"
+	"runtime"
+	"sync"
+)
+
+type (
+	// ShortestPathTree has the least cost from the origin to every reached node
+	// and the edge the cheapest path comes to the node by, the origin has no predecessor.
+	ShortestPathTree struct {
+		Origin       string
+		Costs        map[string]float64
+		Predecessors map[string]Predecessor
+	}
+
+	Predecessor struct {
+		Node string
+		Edge string
+	}
+)
+
+// ShortestPathTree returns the cheapest paths from origin to every node reached through filter.
+func (g Graph) ShortestPathTree(ctx context.Context, origin string, filter Filter) (*ShortestPathTree, error) {
+	dist, previous, err := g.shortestTree(newCanceller(ctx), g.AdjacencyList, origin, 0, nil, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := ShortestPathTree{Origin: origin, Costs: dist, Predecessors: make(map[string]Predecessor, len(previous))}
+	for n, s := range previous {
+		tree.Predecessors[n] = Predecessor{Node: s.node, Edge: s.edge}
+	}
+
+	return &tree, nil
+}
+
+// DistanceMatrix returns the least cost from every origin to every destination, +Inf if destination is unreachable.
+// Row of origin a negative cycle is reached from is nil, its NegativeCycleError is in cycles at the origin index,
+// other rows are answered. Origins are searched concurrently by workers goroutines, runtime.NumCPU() if workers <= 0.
+func (g Graph) DistanceMatrix(ctx context.Context, origins, destinations []string, filter Filter,
+	workers int) (matrix [][]float64, cycles []*NegativeCycleError, err error) {
+	var (
+		targets = make(map[string]bool, len(destinations))
+		indexes = make(chan int, len(origins))
+		errs    = make([]error, len(origins))
+		wg      sync.WaitGroup
+	)
+
+	matrix = make([][]float64, len(origins))
+	cycles = make([]*NegativeCycleError, len(origins))
+
+	for _, d := range destinations {
+		targets[d] = true
+	}
+
+	for i := range origins {
+		indexes <- i
+	}
+	close(indexes)
+
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	for w := 0; w < min(workers, len(origins)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// canceller counts steps, so every worker has its own
+			c := newCanceller(ctx)
+			for i := range indexes {
+				dist, _, err := g.shortestTree(c, g.AdjacencyList, origins[i], 0, targets, filter)
+
+				var nc *NegativeCycleError
+				if errors.As(err, &nc) {
+					cycles[i] = nc
+					continue
+				}
+				if err != nil {
+					errs[i] = err
+					continue
+				}
+
+				row := make([]float64, len(destinations))
+				for j, d := range destinations {
+					cost, ok := dist[d]
+					if !ok {
+						cost = math.Inf(1)
+					}
+					row[j] = cost
+				}
+				matrix[i] = row
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return matrix, cycles, nil
+}
@@ -0,0 +1,129 @@
+package entity
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+func TestDistanceMatrixMatchesCheapestPaths(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	for run := 0; run < 100; run++ {
+		graph := randomGraph(rnd, 7, 12)
+		if run%2 == 1 {
+			graph = randomNegativeGraph(rnd, 7, 12, false)
+		}
+
+		// every node is an origin and a destination, so origin is its own destination as well
+		nodes := make([]string, 0, 7)
+		for i := 0; i < 7; i++ {
+			nodes = append(nodes, strconv.Itoa(i))
+		}
+
+		matrix, cycles, err := graph.DistanceMatrix(context.Background(), nodes, nodes, Filter{}, 1+run%3)
+		if err != nil {
+			t.Fatalf("run %d: unexpected error %v", run, err)
+		}
+
+		for i, origin := range nodes {
+			if cycles[i] != nil {
+				t.Fatalf("run %d: unexpected negative cycle %v", run, cycles[i])
+			}
+
+			tree, err := graph.ShortestPathTree(context.Background(), origin, Filter{})
+			if err != nil {
+				t.Fatalf("run %d: unexpected error %v", run, err)
+			}
+
+			for j, destination := range nodes {
+				expected := math.Inf(1)
+				if all := simplePaths(graph, origin, destination); len(all) > 0 {
+					expected = all[0].TotalCost
+				}
+
+				path, err := graph.GetCheapestPaths(context.Background(), origin, destination, Filter{})
+				if err != nil {
+					t.Fatalf("run %d: unexpected error %v", run, err)
+				}
+
+				cheapest := math.Inf(1)
+				if path != nil {
+					cheapest = path.TotalCost
+				}
+
+				cost, ok := tree.Costs[destination]
+				if !ok {
+					cost = math.Inf(1)
+				}
+
+				if matrix[i][j] != expected || cheapest != expected || cost != expected {
+					t.Errorf("run %d: %s -> %s: expected cost %v, got matrix %v, cheapest %v and tree %v",
+						run, origin, destination, expected, matrix[i][j], cheapest, cost)
+				}
+
+				if ok {
+					assertTreePath(t, graph, tree, destination)
+				}
+			}
+		}
+	}
+}
+
+// assertTreePath checks predecessors lead from destination back to the tree origin at its cost
+func assertTreePath(t *testing.T, graph *Graph, tree *ShortestPathTree, destination string) {
+	t.Helper()
+
+	var cost float64
+	for n, hops := destination, 0; n != tree.Origin; hops++ {
+		p, ok := tree.Predecessors[n]
+		if !ok || hops > len(tree.Costs) {
+			t.Errorf("origin %s: no path back from %s", tree.Origin, destination)
+			return
+		}
+
+		e, ok := graph.Edge(p.Node, p.Edge)
+		if !ok || e.Next != n {
+			t.Errorf("origin %s: edge %s does not lead from %s to %s", tree.Origin, p.Edge, p.Node, n)
+			return
+		}
+
+		cost += e.Cost
+		n = p.Node
+	}
+
+	if cost != tree.Costs[destination] {
+		t.Errorf("origin %s: expected cost %v to %s, got %v over predecessors", tree.Origin, tree.Costs[destination], destination, cost)
+	}
+}
+
+func TestDistanceMatrixNegativeCycle(t *testing.T) {
+	// negative cycle c d is reached from a only
+	graph := Graph{
+		AdjacencyList: map[string][]Edge{
+			"a": {{ID: "a1", Next: "b", Cost: 2}, {ID: "a2", Next: "c", Cost: 1}},
+			"b": nil,
+			"c": {{ID: "c1", Next: "d", Cost: -3}},
+			"d": {{ID: "d1", Next: "c", Cost: 1}},
+			"x": {{ID: "x1", Next: "b", Cost: 4}},
+		},
+		NegativeCosts: true,
+	}
+
+	matrix, cycles, err := graph.DistanceMatrix(context.Background(), []string{"a", "x", "b"}, []string{"b", "x"}, Filter{}, 2)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if matrix[0] != nil || cycles[0] == nil || len(cycles[0].Cycle.Edges) != 2 {
+		t.Errorf("expected negative cycle of origin a, got row %v and %v", matrix[0], cycles[0])
+	}
+
+	for i, expected := range map[int][]float64{1: {4, 0}, 2: {0, math.Inf(1)}} {
+		if cycles[i] != nil || len(matrix[i]) != 2 || matrix[i][0] != expected[0] || matrix[i][1] != expected[1] {
+			t.Errorf("origin %d: expected row %v, got %v and %v", i, expected, matrix[i], cycles[i])
+		}
+	}
+}
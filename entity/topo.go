@@ -0,0 +1,223 @@
+package entity
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"slices"
+)
+
+// BidirectionalEdgeError is returned by TopologicalSort and CriticalPath when the graph has a bidirectional edge,
+// it orders its ends both ways. The edge is not a cycle, FindCycles skips going there and back over it.
+type BidirectionalEdgeError struct {
+	Edge     string
+	From, To string
+}
+
+func (e *BidirectionalEdgeError) Error() string {
+	return fmt.Sprintf("bidirectional edge %s between %s and %s can't be ordered", e.Edge, e.From, e.To)
+}
+
+type (
+	// Schedule is the critical path analysis of DAG where edge cost is duration.
+	// Nodes are events, a node starts when all edges into it are done.
+	Schedule struct {
+		Order    []string                // nodes in topological order
+		Nodes    map[string]NodeSchedule // schedule by node ID
+		Duration float64                 // earliest finish of the whole graph
+		Critical PathsCost               // the longest path, its nodes have no slack
+	}
+
+	NodeSchedule struct {
+		EarliestStart float64
+		LatestStart   float64
+		Slack         float64 // LatestStart - EarliestStart
+	}
+)
+
+// TopologicalSort returns nodes ordered so every edge goes forward, the least node ID is taken when free to choose.
+// If the graph is not a DAG it returns nil order and a cycle blocking the sort, cycles are the ones FindCycles finds.
+// Graph with a bidirectional edge fails with BidirectionalEdgeError.
+// Kahn's algorithm with free nodes in a min-heap.
+func (g Graph) TopologicalSort(ctx context.Context) ([]string, *Cycle, error) {
+	var (
+		c        = newCanceller(ctx)
+		inDegree = make(map[string]int, len(g.AdjacencyList))
+		queue    = &nodeQueue{}
+		order    = make([]string, 0, len(g.AdjacencyList))
+	)
+
+	if err := g.bidirectionalEdge(); err != nil {
+		return nil, nil, err
+	}
+
+	for _, edges := range g.AdjacencyList {
+		for _, e := range edges {
+			inDegree[e.Next]++
+		}
+	}
+
+	for n := range g.AdjacencyList {
+		if inDegree[n] == 0 {
+			heap.Push(queue, n)
+		}
+	}
+
+	for queue.Len() > 0 {
+		if err := c.err(); err != nil {
+			return nil, nil, err
+		}
+
+		n := heap.Pop(queue).(string)
+		order = append(order, n)
+
+		for _, e := range g.AdjacencyList[n] {
+			inDegree[e.Next]--
+			if inDegree[e.Next] == 0 {
+				heap.Push(queue, e.Next)
+			}
+		}
+	}
+
+	if len(order) < len(g.AdjacencyList) {
+		return nil, g.blockingCycle(inDegree), nil
+	}
+
+	return order, nil, nil
+}
+
+// bidirectionalEdge returns BidirectionalEdgeError of the first bidirectional edge in node ID order, nil if there is none.
+// Bidirectional edge is stored in both directions under the same ID.
+func (g Graph) bidirectionalEdge() *BidirectionalEdgeError {
+	edges := make(map[edgeKey]bool)
+	for n, out := range g.AdjacencyList {
+		for _, e := range out {
+			edges[edgeKey{from: n, to: e.Next, id: e.ID}] = true
+		}
+	}
+
+	for _, n := range g.sortedNodes() {
+		for _, e := range g.AdjacencyList[n] {
+			if edges[edgeKey{from: e.Next, to: n, id: e.ID}] {
+				return &BidirectionalEdgeError{Edge: e.ID, From: n, To: e.Next}
+			}
+		}
+	}
+
+	return nil
+}
+
+// blockingCycle finds a cycle among nodes left by Kahn's algorithm.
+// Every left node has an edge from another left node, so walking edges backwards always comes to a node seen before.
+func (g Graph) blockingCycle(inDegree map[string]int) *Cycle {
+	var (
+		left     = make(map[string]bool)
+		previous = make(map[string]step)
+	)
+
+	for n, d := range inDegree {
+		if d > 0 {
+			left[n] = true
+		}
+	}
+
+	for _, n := range g.sortedNodes() {
+		if !left[n] {
+			continue
+		}
+
+		for _, e := range g.AdjacencyList[n] {
+			if _, ok := previous[e.Next]; !ok && left[e.Next] {
+				previous[e.Next] = step{node: n, edge: e.ID}
+			}
+		}
+	}
+
+	var (
+		walk  []string
+		edges []string
+		seen  = make(map[string]int)
+	)
+
+	for n := g.firstOf(left); ; n = previous[n].node {
+		if i, ok := seen[n]; ok {
+			// walk[i:] is the cycle backwards
+			nodes := append(slices.Clone(walk[i:]), n)
+			cycleEdges := slices.Clone(edges[i:])
+			slices.Reverse(nodes)
+			slices.Reverse(cycleEdges)
+
+			return &Cycle{Nodes: nodes, Edges: cycleEdges}
+		}
+
+		seen[n] = len(walk)
+		walk = append(walk, n)
+		edges = append(edges, previous[n].edge)
+	}
+}
+
+// firstOf returns the least node ID of nodes
+func (g Graph) firstOf(nodes map[string]bool) string {
+	first := ""
+	for n := range nodes {
+		if first == "" || n < first {
+			first = n
+		}
+	}
+
+	return first
+}
+
+// CriticalPath schedules DAG where edge cost is duration, the longest of parallel edges counts.
+// If the graph is not a DAG it returns nil schedule and a cycle blocking the sort, see TopologicalSort.
+func (g Graph) CriticalPath(ctx context.Context) (*Schedule, *Cycle, error) {
+	order, cycle, err := g.TopologicalSort(ctx)
+	if err != nil || cycle != nil {
+		return nil, cycle, err
+	}
+
+	var (
+		s = Schedule{Order: order, Nodes: make(map[string]NodeSchedule, len(order))}
+		// earliest start and the edge it comes from
+		earliest = make(map[string]float64, len(order))
+		previous = make(map[string]step)
+		last     string
+	)
+
+	for _, n := range order {
+		for _, e := range g.AdjacencyList[n] {
+			if start := earliest[n] + e.Cost; start > earliest[e.Next] || previous[e.Next].node == "" {
+				earliest[e.Next] = start
+				previous[e.Next] = step{node: n, edge: e.ID}
+			}
+		}
+
+		if last == "" || earliest[n] > earliest[last] {
+			last = n
+		}
+	}
+	s.Duration = earliest[last]
+
+	latest := make(map[string]float64, len(order))
+	for i := len(order) - 1; i >= 0; i-- {
+		n := order[i]
+
+		latest[n] = s.Duration
+		for _, e := range g.AdjacencyList[n] {
+			latest[n] = min(latest[n], latest[e.Next]-e.Cost)
+		}
+
+		s.Nodes[n] = NodeSchedule{EarliestStart: earliest[n], LatestStart: latest[n], Slack: latest[n] - earliest[n]}
+	}
+
+	// walk the longest path back from the node finishing last
+	s.Critical = PathsCost{Path: []string{last}, Edges: make([]string, 0), TotalCost: s.Duration}
+	for n := last; previous[n].node != ""; n = previous[n].node {
+		s.Critical.Path = append(s.Critical.Path, previous[n].node)
+		s.Critical.Edges = append(s.Critical.Edges, previous[n].edge)
+	}
+	slices.Reverse(s.Critical.Path)
+	slices.Reverse(s.Critical.Edges)
+
+	return &s, nil, nil
+}
@@ -0,0 +1,226 @@
+package entity
+
+import (
+	"context"
+	"errors"
+	"graphs/entity/postgre"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestTopologicalSortLeastIDFirst(t *testing.T) {
+	graph := &Graph{AdjacencyList: map[string][]Edge{
+		"a": {{ID: "a1", Next: "b", Cost: 1}},
+		"b": nil,
+		"m": nil,
+	}}
+
+	order, cycle, err := graph.TopologicalSort(context.Background())
+	if err != nil || cycle != nil {
+		t.Fatalf("unexpected cycle %v, error %v", cycle, err)
+	}
+
+	if expected := []string{"a", "b", "m"}; !reflect.DeepEqual(order, expected) {
+		t.Errorf("expected order %v, got %v", expected, order)
+	}
+}
+
+func TestTopologicalSortRandomGraphs(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	for run := 0; run < 200; run++ {
+		graph := randomGraph(rnd, 8, 10)
+
+		order, cycle, err := graph.TopologicalSort(context.Background())
+
+		var be *BidirectionalEdgeError
+		if errors.As(err, &be) {
+			forward, ok := graph.Edge(be.From, be.Edge)
+			back, okBack := graph.Edge(be.To, be.Edge)
+			if !ok || !okBack || forward.Next != be.To || back.Next != be.From {
+				t.Errorf("run %d: edge %s is not bidirectional between %s and %s", run, be.Edge, be.From, be.To)
+			}
+
+			// sort the graph with bidirectional edges left one way
+			graph = oneWay(graph)
+			order, cycle, err = graph.TopologicalSort(context.Background())
+		}
+		if err != nil {
+			t.Fatalf("run %d: unexpected error %v", run, err)
+		}
+
+		if cycle != nil {
+			if order != nil || cycle.Nodes[0] != cycle.Nodes[len(cycle.Nodes)-1] || len(cycle.Edges) != len(cycle.Nodes)-1 {
+				t.Fatalf("run %d: expected closed cycle without order, got %v, %v", run, cycle, order)
+			}
+
+			if len(cycle.Edges) == 2 && cycle.Edges[0] == cycle.Edges[1] {
+				t.Errorf("run %d: cycle %v goes there and back over one edge", run, cycle)
+			}
+
+			for i, id := range cycle.Edges {
+				if e, ok := graph.Edge(cycle.Nodes[i], id); !ok || e.Next != cycle.Nodes[i+1] {
+					t.Errorf("run %d: cycle %v has no edge %s from %s", run, cycle, id, cycle.Nodes[i])
+				}
+			}
+
+			continue
+		}
+
+		if len(order) != len(graph.AdjacencyList) {
+			t.Fatalf("run %d: expected %d nodes, got %v", run, len(graph.AdjacencyList), order)
+		}
+
+		position := make(map[string]int, len(order))
+		for i, n := range order {
+			position[n] = i
+		}
+
+		for n, edges := range graph.AdjacencyList {
+			for _, e := range edges {
+				if position[n] >= position[e.Next] {
+					t.Errorf("run %d: edge %s -> %s goes backward in %v", run, n, e.Next, order)
+				}
+			}
+		}
+
+		// every taken node is the least of nodes whose predecessors are all taken
+		for i, n := range order {
+			for m := range graph.AdjacencyList {
+				if position[m] > i && m < n && freeAt(graph, m, position, i) {
+					t.Errorf("run %d: %s is taken before free %s in %v", run, n, m, order)
+				}
+			}
+		}
+	}
+}
+
+// oneWay returns copy of graph with bidirectional edges kept from the lesser node ID only
+func oneWay(g *Graph) *Graph {
+	res := &Graph{AdjacencyList: make(map[string][]Edge, len(g.AdjacencyList))}
+	for n, edges := range g.AdjacencyList {
+		res.AdjacencyList[n] = nil
+		for _, e := range edges {
+			if back, ok := g.Edge(e.Next, e.ID); ok && back.Next == n && e.Next < n {
+				continue
+			}
+			res.AdjacencyList[n] = append(res.AdjacencyList[n], e)
+		}
+	}
+
+	return res
+}
+
+// freeAt reports every predecessor of node is before position i
+func freeAt(g *Graph, node string, position map[string]int, i int) bool {
+	for n, edges := range g.AdjacencyList {
+		for _, e := range edges {
+			if e.Next == node && position[n] >= i {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func TestCriticalPath(t *testing.T) {
+	graph := &Graph{AdjacencyList: map[string][]Edge{
+		"a": {{ID: "ab", Next: "b", Cost: 3}, {ID: "ac", Next: "c", Cost: 2}},
+		"b": {{ID: "bd", Next: "d", Cost: 4}},
+		"c": {{ID: "cd", Next: "d", Cost: 1}},
+		"d": {{ID: "de", Next: "e", Cost: 2}, {ID: "de2", Next: "e", Cost: 1}},
+		"e": nil,
+	}}
+
+	schedule, cycle, err := graph.CriticalPath(context.Background())
+	if err != nil || cycle != nil {
+		t.Fatalf("unexpected cycle %v, error %v", cycle, err)
+	}
+
+	if schedule.Duration != 9 {
+		t.Errorf("expected duration 9, got %v", schedule.Duration)
+	}
+
+	expected := PathsCost{Path: []string{"a", "b", "d", "e"}, Edges: []string{"ab", "bd", "de"}, TotalCost: 9}
+	if !reflect.DeepEqual(schedule.Critical, expected) {
+		t.Errorf("expected critical path %+v, got %+v", expected, schedule.Critical)
+	}
+
+	for n, s := range map[string]NodeSchedule{
+		"a": {EarliestStart: 0, LatestStart: 0, Slack: 0},
+		"b": {EarliestStart: 3, LatestStart: 3, Slack: 0},
+		"c": {EarliestStart: 2, LatestStart: 6, Slack: 4},
+		"d": {EarliestStart: 7, LatestStart: 7, Slack: 0},
+		"e": {EarliestStart: 9, LatestStart: 9, Slack: 0},
+	} {
+		if schedule.Nodes[n] != s {
+			t.Errorf("node %s: expected %+v, got %+v", n, s, schedule.Nodes[n])
+		}
+	}
+}
+
+func TestCriticalPathCycle(t *testing.T) {
+	graph := &Graph{AdjacencyList: make(map[string][]Edge)}
+	for i := 0; i < 3; i++ {
+		graph.AdjacencyList[strconv.Itoa(i)] = []Edge{{ID: "e" + strconv.Itoa(i), Next: strconv.Itoa((i + 1) % 3), Cost: 1}}
+	}
+
+	schedule, cycle, err := graph.CriticalPath(context.Background())
+	if err != nil || schedule != nil || cycle == nil || len(cycle.Edges) != 3 {
+		t.Errorf("expected blocking cycle of 3 edges, got %v, %v, %v", schedule, cycle, err)
+	}
+}
+
+func TestTopologicalSortBidirectionalEdge(t *testing.T) {
+	graph := NewGraph(postgre.Graph{
+		Nodes: []postgre.Node{{ID: "a"}, {ID: "b"}, {ID: "c"}},
+		Edges: []postgre.Edge{
+			{ID: "a1", PreviousNode: "a", NextNode: "b", Cost: 1},
+			{ID: "b1", PreviousNode: "c", NextNode: "b", Cost: 1, Bidirectional: true},
+		},
+	})
+
+	order, cycle, err := graph.TopologicalSort(context.Background())
+
+	var be *BidirectionalEdgeError
+	if !errors.As(err, &be) || order != nil || cycle != nil {
+		t.Fatalf("expected bidirectional edge error, got %v, %v, %v", order, cycle, err)
+	}
+
+	if expected := (BidirectionalEdgeError{Edge: "b1", From: "b", To: "c"}); *be != expected {
+		t.Errorf("expected %+v, got %+v", expected, *be)
+	}
+
+	if schedule, cycle, err := graph.CriticalPath(context.Background()); !errors.As(err, &be) || schedule != nil || cycle != nil {
+		t.Errorf("expected bidirectional edge error, got %v, %v, %v", schedule, cycle, err)
+	}
+
+	// there and back over one edge is not a cycle for cycles search either
+	if cycles, err := graph.FindCycles(context.Background(), 0); err != nil || len(cycles) != 0 {
+		t.Errorf("expected no cycles, got %v, %v", cycles, err)
+	}
+}
+
+func TestTopologicalSortTwoEdgeCycle(t *testing.T) {
+	graph := &Graph{AdjacencyList: map[string][]Edge{
+		"a": {{ID: "a1", Next: "b", Cost: 1}},
+		"b": {{ID: "b1", Next: "a", Cost: 1}},
+	}}
+
+	order, cycle, err := graph.TopologicalSort(context.Background())
+	if err != nil || order != nil || cycle == nil {
+		t.Fatalf("expected blocking cycle, got %v, %v, %v", order, cycle, err)
+	}
+
+	cycles, err := graph.FindCycles(context.Background(), 0)
+	if err != nil || len(cycles) != 1 {
+		t.Fatalf("expected a cycle, got %v, %v", cycles, err)
+	}
+
+	if !reflect.DeepEqual(*cycle, cycles[0]) {
+		t.Errorf("expected cycle %v, got %v", cycles[0], *cycle)
+	}
+}
@@ -0,0 +1,155 @@
+package entity
+
+import (
+	"context"
+	"math"
+)
+
+// MaxUnorderedVia bounds waypoints visited in any order, search is exponential in their number
+const MaxUnorderedVia = 12
+
+// GetCheapestPathVia returns the cheapest path from start to end going through every node of via.
+// Waypoints are visited in via order, or in the cheapest order if anyOrder is set.
+// Path is stitched from cheapest segments between waypoints, so it may visit a node more than once.
+// If some waypoint is unreachable it returns nil path.
+func (g Graph) GetCheapestPathVia(ctx context.Context, start, end string, via []string, anyOrder bool, filter Filter) (*PathsCost, error) {
+	c := newCanceller(ctx)
+
+	if !anyOrder || len(via) < 2 {
+		return g.viaOrdered(c, filter, append(append([]string{start}, via...), end))
+	}
+
+	return g.viaAnyOrder(c, filter, start, end, via)
+}
+
+// viaOrdered stitches cheapest segments between consecutive stops
+func (g Graph) viaOrdered(c *canceller, filter Filter, stops []string) (*PathsCost, error) {
+	path := &PathsCost{Path: []string{stops[0]}, Edges: make([]string, 0)}
+
+	for i := 0; i < len(stops)-1; i++ {
+		segment, err := g.shortestPath(c, filter, stops[i], stops[i+1], nil, nil)
+		if err != nil || segment == nil {
+			return nil, err
+		}
+
+		path.join(segment)
+	}
+
+	return path, nil
+}
+
+// viaAnyOrder finds the cheapest order of waypoints by Held-Karp dynamic programming
+// over cheapest segments between start, waypoints and end.
+func (g Graph) viaAnyOrder(c *canceller, filter Filter, start, end string, via []string) (*PathsCost, error) {
+	var (
+		n = len(via)
+		// segments[i][j] is the cheapest path from via[i] to via[j], index n is start as origin and end as target
+		segments = make([][]*PathsCost, n+1)
+	)
+
+	for i := range segments {
+		from := start
+		if i < n {
+			from = via[i]
+		}
+
+		segments[i] = make([]*PathsCost, n+1)
+		for j := range segments[i] {
+			to := end
+			if j < n {
+				to = via[j]
+			}
+
+			if i == j {
+				continue
+			}
+
+			segment, err := g.shortestPath(c, filter, from, to, nil, nil)
+			if err != nil {
+				return nil, err
+			}
+			segments[i][j] = segment
+		}
+	}
+
+	// cost[mask][last] is the cheapest path from start through waypoints of mask ending at via[last]
+	var (
+		full     = 1<<n - 1
+		cost     = make([][]float64, full+1)
+		previous = make([][]int, full+1)
+	)
+
+	for mask := range cost {
+		cost[mask] = make([]float64, n)
+		previous[mask] = make([]int, n)
+		for last := range cost[mask] {
+			cost[mask][last] = math.Inf(1)
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		if s := segments[n][i]; s != nil {
+			cost[1<<i][i] = s.TotalCost
+			previous[1<<i][i] = -1
+		}
+	}
+
+	for mask := 1; mask <= full; mask++ {
+		if err := c.err(); err != nil {
+			return nil, err
+		}
+
+		for last := 0; last < n; last++ {
+			if mask&(1<<last) == 0 || math.IsInf(cost[mask][last], 1) {
+				continue
+			}
+
+			for next := 0; next < n; next++ {
+				s := segments[last][next]
+				if mask&(1<<next) != 0 || s == nil {
+					continue
+				}
+
+				if total := cost[mask][last] + s.TotalCost; total < cost[mask|1<<next][next] {
+					cost[mask|1<<next][next] = total
+					previous[mask|1<<next][next] = last
+				}
+			}
+		}
+	}
+
+	best, bestCost := -1, math.Inf(1)
+	for last := 0; last < n; last++ {
+		if s := segments[last][n]; s != nil && cost[full][last]+s.TotalCost < bestCost {
+			best, bestCost = last, cost[full][last]+s.TotalCost
+		}
+	}
+
+	if best < 0 {
+		return nil, nil
+	}
+
+	// restore waypoints order walking back from the last one
+	order := make([]int, 0, n)
+	for mask, last := full, best; last >= 0; {
+		order = append(order, last)
+		mask, last = mask&^(1<<last), previous[mask][last]
+	}
+
+	path := &PathsCost{Path: []string{start}, Edges: make([]string, 0)}
+	from := n
+	for i := len(order) - 1; i >= 0; i-- {
+		path.join(segments[from][order[i]])
+		from = order[i]
+	}
+	path.join(segments[from][n])
+
+	return path, nil
+}
+
+// join appends segment starting at the last node of p
+func (p *PathsCost) join(segment *PathsCost) {
+	p.Path = append(p.Path, segment.Path[1:]...)
+	p.Edges = append(p.Edges, segment.Edges...)
+	p.TotalCost += segment.TotalCost
+}
@@ -0,0 +1,110 @@
+package entity
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+// permutations calls visit with every order of nodes
+func permutations(nodes []string, visit func([]string)) {
+	if len(nodes) <= 1 {
+		visit(nodes)
+		return
+	}
+
+	for i := range nodes {
+		nodes[0], nodes[i] = nodes[i], nodes[0]
+		permutations(nodes[1:], func(rest []string) {
+			visit(append([]string{nodes[0]}, rest...))
+		})
+		nodes[0], nodes[i] = nodes[i], nodes[0]
+	}
+}
+
+func TestGetCheapestPathViaAnyOrderMatchesPermutations(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	for run := 0; run < 200; run++ {
+		graph := randomGraph(rnd, 8, 24)
+		via := []string{"1", "2", "3", "4"}[:1+rnd.Intn(4)]
+
+		best := math.Inf(1)
+		permutations(via, func(order []string) {
+			path, err := graph.GetCheapestPathVia(context.Background(), "0", "7", order, false, Filter{})
+			if err != nil {
+				t.Fatalf("run %d: unexpected error %v", run, err)
+			}
+
+			if path != nil {
+				best = min(best, path.TotalCost)
+			}
+		})
+
+		path, err := graph.GetCheapestPathVia(context.Background(), "0", "7", via, true, Filter{})
+		if err != nil {
+			t.Fatalf("run %d: unexpected error %v", run, err)
+		}
+
+		if path == nil {
+			if !math.IsInf(best, 1) {
+				t.Errorf("run %d: expected path of cost %v via %v, got none", run, best, via)
+			}
+			continue
+		}
+
+		if path.TotalCost != best {
+			t.Errorf("run %d: expected cost %v via %v, got %v", run, best, via, path.TotalCost)
+		}
+
+		// path is a walk over graph edges from start to end through every waypoint
+		visited := make(map[string]bool)
+		for i, id := range path.Edges {
+			if e, ok := graph.Edge(path.Path[i], id); !ok || e.Next != path.Path[i+1] {
+				t.Fatalf("run %d: path %v has no edge %s from %s", run, path, id, path.Path[i])
+			}
+			visited[path.Path[i+1]] = true
+		}
+
+		if path.Path[0] != "0" || path.Path[len(path.Path)-1] != "7" || graph.pathCost(path.Path, path.Edges) != path.TotalCost {
+			t.Errorf("run %d: expected path from 0 to 7 of its edges cost, got %+v", run, path)
+		}
+
+		for _, v := range via {
+			if !visited[v] {
+				t.Errorf("run %d: path %v misses waypoint %s", run, path.Path, v)
+			}
+		}
+	}
+}
+
+func TestGetCheapestPathViaOrder(t *testing.T) {
+	graph := &Graph{AdjacencyList: make(map[string][]Edge)}
+	for _, n := range []string{"s", "a", "b", "e"} {
+		graph.AdjacencyList[n] = nil
+	}
+
+	edge := 0
+	link := func(from, to string, cost float64) {
+		edge++
+		graph.AdjacencyList[from] = append(graph.AdjacencyList[from], Edge{ID: strconv.Itoa(edge), Next: to, Cost: cost})
+	}
+	link("s", "a", 1)
+	link("a", "b", 1)
+	link("b", "a", 10)
+	link("s", "b", 10)
+	link("b", "e", 1)
+	link("a", "e", 10)
+
+	ordered, err := graph.GetCheapestPathVia(context.Background(), "s", "e", []string{"b", "a"}, false, Filter{})
+	if err != nil || ordered == nil || ordered.TotalCost != 14 {
+		t.Errorf("expected ordered path of cost 14, got %+v, %v", ordered, err)
+	}
+
+	anyOrder, err := graph.GetCheapestPathVia(context.Background(), "s", "e", []string{"b", "a"}, true, Filter{})
+	if err != nil || anyOrder == nil || anyOrder.TotalCost != 3 {
+		t.Errorf("expected path of cost 3 in any order, got %+v, %v", anyOrder, err)
+	}
+}
@@ -0,0 +1,88 @@
+package xml
+
+import (
+	"encoding/xml"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Attribute value types, attribute without type is a string
+const (
+	AttributeString = "string"
+	AttributeNumber = "number"
+	AttributeBool   = "bool"
+)
+
+type (
+	// Attributes are free-form key/value pairs of node or edge
+	Attributes struct {
+		XMLName    xml.Name    `xml:"attributes"`
+		Attributes []Attribute `xml:"attribute"`
+	}
+
+	// Attribute is <attribute name="capacity" type="number">10</attribute>
+	Attribute struct {
+		Name  string `xml:"name,attr"`
+		Type  string `xml:"type,attr"`
+		Value string `xml:",chardata"`
+	}
+)
+
+// Typed returns attribute value as string, float64 or bool, numbers must be finite to be stored as JSON
+func (a Attribute) Typed() (interface{}, error) {
+	switch a.Type {
+	case "", AttributeString:
+		return a.Value, nil
+	case AttributeNumber:
+		v, err := strconv.ParseFloat(strings.TrimSpace(a.Value), 64)
+		if err == nil && (math.IsNaN(v) || math.IsInf(v, 0)) {
+			return nil, ErrAttributeValue
+		}
+
+		return v, err
+	case AttributeBool:
+		return strconv.ParseBool(strings.TrimSpace(a.Value))
+	}
+
+	return nil, ErrAttributeType
+}
+
+// Map returns typed values by attribute name, nil if there are no attributes.
+// Invalid attributes are skipped, Validate reports them.
+func (a Attributes) Map() map[string]interface{} {
+	if len(a.Attributes) == 0 {
+		return nil
+	}
+
+	values := make(map[string]interface{}, len(a.Attributes))
+	for _, attr := range a.Attributes {
+		if v, err := attr.Typed(); err == nil && attr.Name != "" {
+			values[attr.Name] = v
+		}
+	}
+
+	return values
+}
+
+// validate reports attribute violations of node or edge id
+func (a Attributes) validate(id string, line, column int, add func(err *ValidationError)) {
+	names := make(map[string]bool, len(a.Attributes))
+	for _, attr := range a.Attributes {
+		switch {
+		case attr.Name == "":
+			add(&ValidationError{Err: ErrMissingAttributeName, ID: id, Line: line, Column: column})
+			continue
+		case names[attr.Name]:
+			add(&ValidationError{Err: ErrDuplicateAttribute, ID: id, Attribute: attr.Name, Line: line, Column: column})
+		}
+		names[attr.Name] = true
+
+		if _, err := attr.Typed(); errors.Is(err, ErrAttributeType) {
+			add(&ValidationError{Err: ErrAttributeType, ID: id, Attribute: attr.Name, Line: line, Column: column})
+		} else if err != nil {
+			add(&ValidationError{Err: ErrAttributeValue, ID: id, Attribute: attr.Name, Line: line, Column: column})
+		}
+	}
+}
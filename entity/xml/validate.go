@@ -0,0 +1,86 @@
+package xml
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrGraphIDName   = errors.New("graph must have both <id> and <name>")
+	ErrNoNodes       = errors.New("at least one <node> must be present in the <nodes> group")
+	ErrMissingNodeID = errors.New("node must have <id>")
+	ErrDuplicateNode = errors.New("duplicate <id> tags for nodes are not allowed")
+	ErrMissingEdgeID = errors.New("edge must have <id>")
+	ErrDuplicateEdge = errors.New("duplicate <id> tags for edges are not allowed")
+	ErrUndefinedFrom = errors.New("undefined node in <from> tag of edge")
+	ErrUndefinedTo   = errors.New("undefined node in <to> tag of edge")
+	ErrSelfLoop      = errors.New("edge pointed to itself")
+	ErrNegativeCost  = errors.New("cost must be greather than 0")
+
+	ErrMissingAttributeName = errors.New("attribute must have name")
+	ErrDuplicateAttribute   = errors.New("duplicate attribute names are not allowed")
+	ErrAttributeType        = errors.New("attribute type must be string, number or bool")
+	ErrAttributeValue       = errors.New("attribute value does not match its type")
+)
+
+// ValidationError is a single graph violation, ID is the offending node or edge ID,
+// Node is the undefined node referred by edge, Attribute is the offending attribute name.
+// Line and Column point to the element start tag when graph is decoded from XML, zero otherwise.
+type ValidationError struct {
+	Err       error
+	ID        string
+	Node      string
+	Attribute string
+	Line      int
+	Column    int
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+
+	if e.Line > 0 {
+		fmt.Fprintf(&b, "line %d:%d: ", e.Line, e.Column)
+	}
+
+	b.WriteString(e.Err.Error())
+
+	if e.ID != "" {
+		fmt.Fprintf(&b, ", id: %s", e.ID)
+	}
+
+	if e.Node != "" {
+		fmt.Fprintf(&b, ", node: %s", e.Node)
+	}
+
+	if e.Attribute != "" {
+		fmt.Fprintf(&b, ", attribute: %s", e.Attribute)
+	}
+
+	return b.String()
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationErrors is all violations found in the graph
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+
+	return fmt.Sprintf("%d validation errors:\n%s", len(e), strings.Join(msgs, "\n"))
+}
+
+func (e ValidationErrors) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+	for _, err := range e {
+		errs = append(errs, err)
+	}
+
+	return errs
+}
@@ -0,0 +1,105 @@
+package xml
+
+import (
+	"encoding/xml"
+	"errors"
+	"testing"
+)
+
+const invalidGraph = `<graph>
+    <id>g0</id>
+    <name>invalid</name>
+    <nodes>
+        <node><id>a</id><name>A</name></node>
+        <node><id>b</id><name>B</name></node>
+        <node><id>a</id><name>A again</name></node>
+    </nodes>
+    <edges>
+        <node><id>a1</id><from>a</from><to>b</to><cost>1</cost></node>
+        <node><id>a1</id><from>b</from><to>a</to><cost>1</cost></node>
+        <node><from>a</from><to>b</to><cost>1</cost></node>
+        <node><id>x1</id><from>x</from><to>b</to><cost>1</cost></node>
+        <node><id>y1</id><from>a</from><to>y</to><cost>1</cost></node>
+        <node><id>b1</id><from>b</from><to>b</to><cost>1</cost></node>
+        <node><id>n1</id><from>b</from><to>a</to><cost>-1</cost></node>
+    </edges>
+</graph>`
+
+func TestValidateCollectsAllErrors(t *testing.T) {
+	var graph Graph
+	if err := xml.Unmarshal([]byte(invalidGraph), &graph); err != nil {
+		t.Fatalf("unexpected decode error %v", err)
+	}
+
+	err := graph.Validate(false)
+
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected ValidationErrors, got %v", err)
+	}
+
+	expected := []error{ErrDuplicateNode, ErrDuplicateEdge, ErrMissingEdgeID, ErrUndefinedFrom, ErrUndefinedTo, ErrSelfLoop, ErrNegativeCost}
+	for _, e := range expected {
+		if !errors.Is(err, e) {
+			t.Errorf("expected %q among errors, got %v", e, err)
+		}
+	}
+
+	if len(errs) != len(expected) {
+		t.Errorf("expected %d errors, got %d: %v", len(expected), len(errs), err)
+	}
+
+	for _, e := range errs {
+		if e.Line == 0 || e.Column == 0 {
+			t.Errorf("expected position of %q, got %d:%d", e, e.Line, e.Column)
+		}
+	}
+}
+
+func TestValidateAllowNegativeCosts(t *testing.T) {
+	var graph Graph
+	if err := xml.Unmarshal([]byte(invalidGraph), &graph); err != nil {
+		t.Fatalf("unexpected decode error %v", err)
+	}
+
+	if err := graph.Validate(true); errors.Is(err, ErrNegativeCost) {
+		t.Errorf("expected negative cost to be allowed, got %v", err)
+	}
+}
+
+func TestValidateAttributes(t *testing.T) {
+	tests := []struct {
+		attributes string
+		err        error
+	}{
+		{attributes: `<attribute name="capacity" type="number"> 10 </attribute><attribute name="open" type="bool">true</attribute>`},
+		{attributes: `<attribute name="capacity" type="number">NaN</attribute>`, err: ErrAttributeValue},
+		{attributes: `<attribute name="capacity" type="number">+Inf</attribute>`, err: ErrAttributeValue},
+		{attributes: `<attribute name="capacity" type="number">-Inf</attribute>`, err: ErrAttributeValue},
+		{attributes: `<attribute name="capacity" type="number">ten</attribute>`, err: ErrAttributeValue},
+		{attributes: `<attribute name="open" type="bool">yes</attribute>`, err: ErrAttributeValue},
+		{attributes: `<attribute name="capacity" type="int">10</attribute>`, err: ErrAttributeType},
+		{attributes: `<attribute>10</attribute>`, err: ErrMissingAttributeName},
+		{attributes: `<attribute name="owner">A</attribute><attribute name="owner">B</attribute>`, err: ErrDuplicateAttribute},
+	}
+
+	for _, tt := range tests {
+		doc := `<graph><id>g0</id><name>attributes</name><nodes><node><id>a</id><attributes>` + tt.attributes +
+			`</attributes></node></nodes><edges/></graph>`
+
+		var graph Graph
+		if err := xml.Unmarshal([]byte(doc), &graph); err != nil {
+			t.Fatalf("%s: unexpected decode error %v", tt.attributes, err)
+		}
+
+		err := graph.Validate(false)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: expected %v, got %v", tt.attributes, tt.err, err)
+		}
+
+		// invalid attributes are not stored
+		if _, ok := graph.Nodes.Nodes[0].Attributes.Map()["capacity"]; ok && tt.err == ErrAttributeValue {
+			t.Errorf("%s: expected invalid value to be skipped", tt.attributes)
+		}
+	}
+}
@@ -2,7 +2,6 @@ package xml
 
 import (
 	"encoding/xml"
-	"fmt"
 )
 
 type (
@@ -10,8 +9,10 @@ type (
 		XMLName xml.Name `xml:"graph"`
 		ID      string   `xml:"id"`
 		Name    string   `xml:"name"`
-		Nodes   Nodes    `xml:"nodes"`
-		Edges   Edges    `xml:"edges"`
+		// Directed is the default of edges, edges are directed if it is not set
+		Directed *bool `xml:"directed"`
+		Nodes    Nodes `xml:"nodes"`
+		Edges    Edges `xml:"edges"`
 	}
 
 	Nodes struct {
@@ -20,9 +21,12 @@ type (
 	}
 
 	Node struct {
-		XMLName xml.Name `xml:"node"`
-		ID      string   `xml:"id"`
-		Name    string   `xml:"name"`
+		XMLName    xml.Name   `xml:"node"`
+		ID         string     `xml:"id"`
+		Name       string     `xml:"name"`
+		Attributes Attributes `xml:"attributes"`
+		Line       int        `xml:"-"`
+		Column     int        `xml:"-"`
 	}
 
 	Edges struct {
@@ -33,46 +37,137 @@ type (
 	Edge struct {
 		XMLName xml.Name `xml:"node"`
 		ID      string   `xml:"id"`
+		Name    string   `xml:"name"`
 		From    string   `xml:"from"`
 		To      string   `xml:"to"`
 		Cost    float64  `xml:"cost"`
+		// Directed overrides the graph default, bidirectional edge goes from To to From as well
+		Directed   *bool      `xml:"directed"`
+		Attributes Attributes `xml:"attributes"`
+		Line       int        `xml:"-"`
+		Column     int        `xml:"-"`
 	}
 )
 
-func (g *Graph) Validate() error {
+// UnmarshalXML decodes node and keeps its position in XML document.
+func (n *Node) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type node Node
+
+	line, column := d.InputPos()
+
+	var v node
+	if err := d.DecodeElement(&v, &start); err != nil {
+		return err
+	}
+
+	*n = Node(v)
+	n.Line, n.Column = line, column
+
+	return nil
+}
+
+// UnmarshalXML decodes edge and keeps its position in XML document.
+func (e *Edge) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type edge Edge
+
+	line, column := d.InputPos()
+
+	var v edge
+	if err := d.DecodeElement(&v, &start); err != nil {
+		return err
+	}
+
+	*e = Edge(v)
+	e.Line, e.Column = line, column
+
+	return nil
+}
+
+// IsDirected reports edge goes only from From to To, edge setting wins over the graph default.
+func (g *Graph) IsDirected(e Edge) bool {
+	if e.Directed != nil {
+		return *e.Directed
+	}
+
+	if g.Directed != nil {
+		return *g.Directed
+	}
+
+	return true
+}
+
+// Validate checks whole graph and returns ValidationErrors with every violation found.
+// Negative edge costs are violations unless allowNegativeCosts is set.
+func (g *Graph) Validate(allowNegativeCosts bool) error {
+	var errs ValidationErrors
+
+	addError := func(err *ValidationError) {
+		errs = append(errs, err)
+	}
+	add := func(err error, id string, line, column int) {
+		errs = append(errs, &ValidationError{Err: err, ID: id, Line: line, Column: column})
+	}
+	addUndefined := func(err error, edge Edge, node string) {
+		errs = append(errs, &ValidationError{Err: err, ID: edge.ID, Node: node, Line: edge.Line, Column: edge.Column})
+	}
+
 	if g.ID == "" || g.Name == "" {
-		return fmt.Errorf("graph must have both <id> and <name>")
+		add(ErrGraphIDName, g.ID, 0, 0)
 	}
 
 	// Validate at least one <node> in the <nodes> group
 	if len(g.Nodes.Nodes) == 0 {
-		return fmt.Errorf("at least one <node> must be present in the <nodes> group")
+		add(ErrNoNodes, "", 0, 0)
 	}
 
 	// Validate unique <id> tags for nodes
 	nodeIDs := make(map[string]bool)
 	for _, node := range g.Nodes.Nodes {
-		if nodeIDs[node.ID] {
-			return fmt.Errorf("duplicate <id> tags for nodes are not allowed")
+		switch {
+		case node.ID == "":
+			add(ErrMissingNodeID, "", node.Line, node.Column)
+		case nodeIDs[node.ID]:
+			add(ErrDuplicateNode, node.ID, node.Line, node.Column)
 		}
 		nodeIDs[node.ID] = true
+
+		node.Attributes.validate(node.ID, node.Line, node.Column, addError)
 	}
 
+	edgeIDs := make(map[string]bool)
 	for _, edge := range g.Edges.Edges {
+		// Validate unique <id> tags for edges
+		switch {
+		case edge.ID == "":
+			add(ErrMissingEdgeID, "", edge.Line, edge.Column)
+		case edgeIDs[edge.ID]:
+			add(ErrDuplicateEdge, edge.ID, edge.Line, edge.Column)
+		}
+		edgeIDs[edge.ID] = true
+
 		// Validate <from> and <to> tags in edges correspond to defined nodes
-		if !nodeIDs[edge.From] || !nodeIDs[edge.To] {
-			return fmt.Errorf("undefined nodes in <from> or <to> tags in edges")
+		if !nodeIDs[edge.From] || edge.From == "" {
+			addUndefined(ErrUndefinedFrom, edge, edge.From)
+		}
+		if !nodeIDs[edge.To] || edge.To == "" {
+			addUndefined(ErrUndefinedTo, edge, edge.To)
 		}
 
 		// Validate <from> and <to> tags in edges not equals
 		if edge.From == edge.To {
-			return fmt.Errorf(fmt.Sprintf("edge id: %s pointed to itself", edge.ID))
+			add(ErrSelfLoop, edge.ID, edge.Line, edge.Column)
 		}
 
 		// Validate cost must be greater than 0
-		if edge.Cost < 0 {
-			return fmt.Errorf(fmt.Sprintf("cost must be greather than 0 for edge id: %s", edge.ID))
+		if edge.Cost < 0 && !allowNegativeCosts {
+			add(ErrNegativeCost, edge.ID, edge.Line, edge.Column)
 		}
+
+		edge.Attributes.validate(edge.ID, edge.Line, edge.Column, addError)
+	}
+
+	if len(errs) > 0 {
+		return errs
 	}
 
 	return nil
@@ -7,12 +7,18 @@ import (
 	"fmt"
 	"graphs/constant"
 	"graphs/entity"
+	"graphs/entity/dot"
+	"graphs/entity/graphml"
 	"graphs/entity/postgre"
 	xmlentity "graphs/entity/xml"
 	"graphs/repository/postges"
 	"graphs/repository/receiver"
+	"io"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
 	"syscall"
 
 	"github.com/jmoiron/sqlx"
@@ -24,47 +30,91 @@ import (
 	_ "github.com/lib/pq"
 )
 
+// logOut is service log output, stdout is left for answers in NDJSON mode
+var logOut io.Writer = os.Stdout
+
 func main() {
+	if viper.GetString("STDIN_MODE") == constant.StdinModeNDJSON {
+		logOut = os.Stderr
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	setupGracefulShutdown(cancel)
 
 	// init DB
 	db, err := connectDB()
 	if err != nil {
-		fmt.Printf("Error connect to db: %v\n", err)
+		fmt.Fprintf(logOut, "Error connect to db: %v\n", err)
 		return
 	}
 	defer db.Close()
 
 	graphRepo := postges.NewGraphRepo(db)
-	err = downloadXMLGraphToDB(ctx, graphRepo)
+	graphID, err := downloadGraphFileToDB(ctx, graphRepo)
+	if err != nil {
+		fmt.Fprintln(logOut, err)
+		return
+	}
+
+	// read graphs from DB and make graph structures, downloaded graph is default
+	graphs, err := loadGraphs(ctx, graphRepo, graphID)
 	if err != nil {
-		fmt.Println(err)
+		fmt.Fprintf(logOut, "%v\n", err)
 		return
 	}
 
-	//Check if graph has cycle via DFS(Depth First Search).
-	//Recursive query - add to path viewed edges, and check if we already pass the edge.
-	cyclePath, err := graphRepo.GetGraphCycle(ctx)
+	//Check if graph has cycle via Johnson's elementary cycles search.
+	var cycleEdges []string
+	graph, _ := graphs.Get(graphID)
+	cycles, err := graph.FindCycles(ctx, 1)
 	if err != nil {
-		fmt.Printf("Error GetGraphCycle: %v\n", err)
+		fmt.Fprintf(logOut, "Error FindCycles: %v\n", err)
 		return
 	}
 
-	if len(cyclePath) > 0 {
-		fmt.Printf("Found Cycle in graph: %v\n", cyclePath)
+	if len(cycles) > 0 {
+		cycleEdges = cycles[0].Edges
+		fmt.Fprintf(logOut, "Found Cycle in graph: nodes %v, edges %v\n", cycles[0].Nodes, cycles[0].Edges)
 	} else {
-		fmt.Println("Cycle in graph not found.")
+		fmt.Fprintln(logOut, "Cycle in graph not found.")
 	}
 
-	// read graph from DB and make graph structure
-	graphDB, err := graphRepo.GetGraph(ctx)
+	err = exportGraph(ctx, graphRepo, graphID, cycleEdges)
 	if err != nil {
-		fmt.Printf("%v\n", err)
+		fmt.Fprintln(logOut, err)
+		return
+	}
+
+	// Start HTTP listener alongside stdin
+	var wg sync.WaitGroup
+	queryConfig := newQueryConfig()
+	if httpConfig := newHTTPConfig(); httpConfig.Addr != "" {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := receiver.ServeHTTP(ctx, graphs, httpConfig, queryConfig); err != nil {
+				fmt.Fprintf(logOut, "%v\n", err)
+				cancel()
+			}
+		}()
 	}
 
 	// Start input message listener
-	receiver.Receive(ctx, entity.NewGraph(*graphDB))
+	switch {
+	case !viper.GetBool("STDIN_ENABLED"):
+		<-ctx.Done()
+	case viper.GetString("STDIN_MODE") == constant.StdinModeNDJSON:
+		err = receiver.ReceiveNDJSON(ctx, os.Stdin, os.Stdout, graphs, queryConfig, viper.GetBool("STDIN_STREAM"))
+		if err != nil {
+			fmt.Fprintln(logOut, err)
+		}
+		// input is over, shutdown HTTP server as well
+		cancel()
+	default:
+		receiver.Receive(ctx, graphs, queryConfig)
+	}
+
+	wg.Wait()
 }
 
 func setupGracefulShutdown(stop func()) {
@@ -72,11 +122,29 @@ func setupGracefulShutdown(stop func()) {
 	signal.Notify(signalChannel, os.Interrupt, syscall.SIGTERM)
 	go func() {
 		<-signalChannel
-		fmt.Println("Got Interrupt signal")
+		fmt.Fprintln(logOut, "Got Interrupt signal")
 		stop()
 	}()
 }
 
+func newQueryConfig() receiver.Config {
+	return receiver.Config{
+		Workers:        viper.GetInt("QUERY_WORKERS"),
+		QueryTimeout:   viper.GetDuration("QUERY_TIMEOUT"),
+		RequestTimeout: viper.GetDuration("REQUEST_TIMEOUT"),
+	}
+}
+
+func newHTTPConfig() receiver.HTTPConfig {
+	return receiver.HTTPConfig{
+		Addr:            viper.GetString("HTTP_ADDR"),
+		ReadTimeout:     viper.GetDuration("HTTP_READ_TIMEOUT"),
+		WriteTimeout:    viper.GetDuration("HTTP_WRITE_TIMEOUT"),
+		RequestTimeout:  viper.GetDuration("HTTP_REQUEST_TIMEOUT"),
+		ShutdownTimeout: viper.GetDuration("HTTP_SHUTDOWN_TIMEOUT"),
+	}
+}
+
 func connectDB() (*sqlx.DB, error) {
 	var (
 		host     = viper.GetString("DB_HOST")
@@ -103,13 +171,13 @@ func connectDB() (*sqlx.DB, error) {
 		return nil, fmt.Errorf("failed to ping to postgres DB")
 	}
 
-	fmt.Println("checking DB migrations")
+	fmt.Fprintln(logOut, "checking DB migrations")
 
 	if err := applySchemaMigrationWithDatabaseInstance(constant.DBDriverName, db); err != nil {
 		return nil, fmt.Errorf("failed to migrate postgres DB schema")
 	}
 
-	fmt.Println("DB connected")
+	fmt.Fprintln(logOut, "DB connected")
 
 	return sqlx.NewDb(db, "postgres"), nil
 }
@@ -137,39 +205,158 @@ func applySchemaMigrationWithDatabaseInstance(databaseName string, db *sql.DB) e
 
 }
 
-func downloadXMLGraphToDB(ctx context.Context, graphRepo *postges.GraphRepo) error {
-	xmlFilePath := "graph.xml"
+func downloadGraphFileToDB(ctx context.Context, graphRepo *postges.GraphRepo) (string, error) {
+	var (
+		filePath = viper.GetString("GRAPH_FILE")
+		format   = graphFileFormat(filePath, viper.GetString("GRAPH_FORMAT"))
+	)
 
-	// Read XML
-	body, err := os.ReadFile(xmlFilePath)
+	graphXML, err := readGraphFile(filePath, format)
 	if err != nil {
-		return fmt.Errorf("error reading XML file: %w", err)
+		return "", err
 	}
 
-	// Used standard library for XML parsing
-	// If needed more performance or/and xsd validation I would rather use library https://github.com/lestrrat-go/libxml2
-	var graphXML xmlentity.Graph
-	err = xml.Unmarshal(body, &graphXML)
+	err = graphXML.Validate(viper.GetBool("GRAPH_NEGATIVE_COSTS"))
 	if err != nil {
-		return fmt.Errorf("error unmarshalling XML: %w", err)
-
+		return "", fmt.Errorf("error validate graph %s: %w", format, err)
 	}
 
-	err = graphXML.Validate()
+	fmt.Fprintf(logOut, "Graph ID: %s\n", graphXML.ID)
+	fmt.Fprintf(logOut, "Graph Name: %s\n", graphXML.Name)
+
+	graph := postgre.NewGraph(*graphXML)
+
+	//save graph to DB in Transactions
+	changes, err := graphRepo.UpsertGraph(ctx, graph)
 	if err != nil {
-		return fmt.Errorf("error validate graph XML: %w", err)
+		return "", fmt.Errorf("error upsert graph into DB: %w", err)
 	}
 
-	fmt.Printf("Graph ID: %s\n", graphXML.ID)
-	fmt.Printf("Graph Name: %s\n", graphXML.Name)
+	fmt.Fprintf(logOut, "Graph changes: %s\n", changes)
 
-	graph := postgre.NewGraph(graphXML)
+	return graph.ID, nil
+}
 
-	//save graph to DB in Transactions
-	err = graphRepo.UpsertGraph(ctx, graph)
+// graphFileFormat returns format of graph file to load, set explicitly or detected by file extension
+func graphFileFormat(filePath, format string) string {
+	if format != "" {
+		return format
+	}
+
+	if strings.ToLower(filepath.Ext(filePath)) == ".graphml" {
+		return constant.GraphFormatGraphML
+	}
+
+	return constant.GraphFormatXML
+}
+
+// exportFileFormat returns format of export file, DOT is export only
+func exportFileFormat(filePath, format string) string {
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(filePath)) {
+		case ".dot", ".gv":
+			return constant.GraphFormatDOT
+		}
+	}
+
+	return graphFileFormat(filePath, format)
+}
+
+func readGraphFile(filePath, format string) (*xmlentity.Graph, error) {
+	switch format {
+	case constant.GraphFormatXML:
+		// Read XML
+		body, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("error reading XML file: %w", err)
+		}
+
+		// Used standard library for XML parsing
+		// If needed more performance or/and xsd validation I would rather use library https://github.com/lestrrat-go/libxml2
+		var graphXML xmlentity.Graph
+		err = xml.Unmarshal(body, &graphXML)
+		if err != nil {
+			return nil, fmt.Errorf("error unmarshalling XML: %w", err)
+		}
+
+		return &graphXML, nil
+	case constant.GraphFormatGraphML:
+		f, err := os.Open(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("error reading GraphML file: %w", err)
+		}
+		defer f.Close()
+
+		return graphml.Decode(f)
+	case constant.GraphFormatDOT:
+		return nil, fmt.Errorf("graph file format %q is export only", format)
+	default:
+		return nil, fmt.Errorf("unknown graph file format %q", format)
+	}
+}
+
+// exportGraph writes stored graph to GRAPH_EXPORT_FILE if it is set, DOT export highlights found cycle
+func exportGraph(ctx context.Context, graphRepo *postges.GraphRepo, graphID string, cycleEdges []string) error {
+	filePath := viper.GetString("GRAPH_EXPORT_FILE")
+	if filePath == "" {
+		return nil
+	}
+
+	graphDB, err := graphRepo.GetGraph(ctx, graphID)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(filePath)
 	if err != nil {
-		return fmt.Errorf("error upsert graph into DB: %w", err)
+		return fmt.Errorf("error creating export file: %w", err)
 	}
+	defer f.Close()
+
+	switch format := exportFileFormat(filePath, viper.GetString("GRAPH_EXPORT_FORMAT")); format {
+	case constant.GraphFormatGraphML:
+		err = graphml.Encode(f, *graphDB)
+	case constant.GraphFormatDOT:
+		err = dot.Encode(f, *graphDB, dot.Highlight{Edges: cycleEdges})
+	default:
+		err = fmt.Errorf("export to %q format is not supported", format)
+	}
+	if err != nil {
+		return fmt.Errorf("error export graph: %w", err)
+	}
+
+	fmt.Fprintf(logOut, "Graph exported to %s\n", filePath)
 
 	return nil
 }
+
+// loadGraphs reads all stored graphs from DB.
+// Graph stored with negative costs is rejected unless GRAPH_NEGATIVE_COSTS is set, as graph file is
+func loadGraphs(ctx context.Context, graphRepo *postges.GraphRepo, defaultID string) (*entity.Graphs, error) {
+	list, err := graphRepo.ListGraphs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	allowNegativeCosts := viper.GetBool("GRAPH_NEGATIVE_COSTS")
+
+	graphsDB := make([]postgre.Graph, 0, len(list))
+	for _, g := range list {
+		graphDB, err := graphRepo.GetGraph(ctx, g.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range graphDB.Edges {
+			if e.Cost < 0 && !allowNegativeCosts {
+				return nil, fmt.Errorf("graph %s: edge %s has negative cost %v, set GRAPH_NEGATIVE_COSTS=true to load it", g.ID, e.ID, e.Cost)
+			}
+		}
+
+		graphsDB = append(graphsDB, *graphDB)
+	}
+
+	fmt.Fprintf(logOut, "Loaded %d graphs\n", len(graphsDB))
+
+	return entity.NewGraphs(defaultID, graphsDB), nil
+}
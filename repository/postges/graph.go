@@ -10,6 +10,8 @@ import (
 	"graphs/entity/postgre"
 )
 
+var ErrGraphNotFound = errors.New("graph not found")
+
 type GraphRepo struct {
 	db *sqlx.DB
 }
@@ -20,36 +22,56 @@ func NewGraphRepo(db *sqlx.DB) *GraphRepo {
 	}
 }
 
-// UpsertGraph - Rewrite graph
-func (g *GraphRepo) UpsertGraph(ctx context.Context, graph *postgre.Graph) error {
+// UpsertGraph - Apply difference between stored graph and graph with the same ID, other stored graphs are kept
+func (g *GraphRepo) UpsertGraph(ctx context.Context, graph *postgre.Graph) (*postgre.GraphChanges, error) {
+	var changes *postgre.GraphChanges
+
 	err := g.runInTransaction(ctx, func(tx *sqlx.Tx) error {
-		// Rewrite graph tables in transaction
-		err := truncateTable(ctx, tx, "edges")
+		// Lock stored graph row so concurrent upserts of the same graph are serialized
+		stored, err := getGraph(ctx, tx, graph.ID, true)
+		if err != nil && !errors.Is(err, ErrGraphNotFound) {
+			return err
+		}
+
+		changes = postgre.Diff(stored, graph)
+
+		// Order matters: edges may be moved to added nodes or away from removed ones
+		err = g.DeleteEdges(ctx, tx, graph.ID, changes.RemovedEdges)
 		if err != nil {
-			return fmt.Errorf("table edges: %w", err)
+			return err
 		}
 
-		err = truncateTable(ctx, tx, "nodes")
+		switch {
+		case changes.Created:
+			err = g.InsertGraph(ctx, tx, graph)
+		case changes.NameChanged:
+			err = g.UpdateGraph(ctx, tx, graph)
+		}
 		if err != nil {
-			return fmt.Errorf("table nodes: %w", err)
+			return err
 		}
 
-		err = truncateTable(ctx, tx, "graphs")
+		err = g.InsertNodes(ctx, tx, changes.AddedNodes)
 		if err != nil {
-			return fmt.Errorf("table graphs: %w", err)
+			return err
 		}
 
-		err = g.InsertGraph(ctx, tx, graph)
+		err = g.UpdateNodes(ctx, tx, changes.ChangedNodes)
 		if err != nil {
 			return err
 		}
 
-		err = g.InsertNodes(ctx, tx, graph.Nodes)
+		err = g.UpdateEdges(ctx, tx, changes.ChangedEdges)
 		if err != nil {
 			return err
 		}
 
-		err = g.InsertEdges(ctx, tx, graph.Edges)
+		err = g.InsertEdges(ctx, tx, changes.AddedEdges)
+		if err != nil {
+			return err
+		}
+
+		err = g.DeleteNodes(ctx, tx, graph.ID, changes.RemovedNodes)
 		if err != nil {
 			return err
 		}
@@ -57,10 +79,10 @@ func (g *GraphRepo) UpsertGraph(ctx context.Context, graph *postgre.Graph) error
 		return nil
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return changes, nil
 }
 
 // InsertGraph ...
@@ -74,9 +96,24 @@ func (g *GraphRepo) InsertGraph(ctx context.Context, tx *sqlx.Tx, graph *postgre
 	return nil
 }
 
+// UpdateGraph ...
+func (g *GraphRepo) UpdateGraph(ctx context.Context, tx *sqlx.Tx, graph *postgre.Graph) error {
+	q := `UPDATE graphs SET name = :name WHERE id = :id`
+	_, err := tx.NamedExecContext(ctx, q, graph)
+	if err != nil {
+		return fmt.Errorf("failed to update graph: %w", err)
+	}
+
+	return nil
+}
+
 // InsertNodes ...
 func (g *GraphRepo) InsertNodes(ctx context.Context, tx *sqlx.Tx, nodes []postgre.Node) error {
-	q := `INSERT INTO nodes (id, name, graph_id) VALUES (:id, :name, :graph_id)`
+	if len(nodes) == 0 {
+		return nil
+	}
+
+	q := `INSERT INTO nodes (id, name, graph_id, attributes) VALUES (:id, :name, :graph_id, :attributes)`
 	_, err := tx.NamedExecContext(ctx, q, nodes)
 	if err != nil {
 		return fmt.Errorf("failed to insert nodes: %w", err)
@@ -87,7 +124,12 @@ func (g *GraphRepo) InsertNodes(ctx context.Context, tx *sqlx.Tx, nodes []postgr
 
 // InsertEdges ...
 func (g *GraphRepo) InsertEdges(ctx context.Context, tx *sqlx.Tx, edges []postgre.Edge) error {
-	q := `INSERT INTO edges (id, previous_node, next_node, cost) VALUES (:id, :previous_node, :next_node, :cost)`
+	if len(edges) == 0 {
+		return nil
+	}
+
+	q := `INSERT INTO edges (id, name, previous_node, next_node, cost, graph_id, bidirectional, attributes)
+		VALUES (:id, :name, :previous_node, :next_node, :cost, :graph_id, :bidirectional, :attributes)`
 	_, err := tx.NamedExecContext(ctx, q, edges)
 	if err != nil {
 		return fmt.Errorf("failed to insert edges: %w", err)
@@ -96,111 +138,149 @@ func (g *GraphRepo) InsertEdges(ctx context.Context, tx *sqlx.Tx, edges []postgr
 	return nil
 }
 
-func (g *GraphRepo) GetGraphCycle(ctx context.Context) ([]string, error) {
-	var res = make([]string, 0)
-
-	query := `WITH RECURSIVE search_graph(previous_node, next_node, id, depth, path, cycle)
-                     AS (
-          SELECT e.previous_node, e.next_node, e.id, 1,
-                 ARRAY[e.id]::varchar[],
-                 false
-          FROM edges e
-          UNION ALL
-          SELECT e.previous_node, e.next_node, e.id, sg.depth + 1,
-                 path || e.id,
-                 e.id = ANY(path)
-          FROM edges e, search_graph sg
-          WHERE e.previous_node = sg.next_node AND NOT cycle
-            and depth < 100
-      )
-	  SELECT path
-	  FROM search_graph
-	  WHERE cycle
-	  limit 1;`
+// UpdateNodes ...
+func (g *GraphRepo) UpdateNodes(ctx context.Context, tx *sqlx.Tx, nodes []postgre.Node) error {
+	q := `UPDATE nodes SET name = :name, attributes = :attributes WHERE graph_id = :graph_id AND id = :id`
+	for _, node := range nodes {
+		_, err := tx.NamedExecContext(ctx, q, node)
+		if err != nil {
+			return fmt.Errorf("failed to update node %s: %w", node.ID, err)
+		}
+	}
 
-	// Execute the query
-	err := g.db.GetContext(ctx, pq.Array(&res), query)
-	if err != nil && !errors.Is(err, sql.ErrNoRows) {
-		return nil, fmt.Errorf("failed to GetGraphCycle: %w", err)
+	return nil
+}
+
+// UpdateEdges ...
+func (g *GraphRepo) UpdateEdges(ctx context.Context, tx *sqlx.Tx, edges []postgre.Edge) error {
+	q := `UPDATE edges SET name = :name, previous_node = :previous_node, next_node = :next_node, cost = :cost,
+		bidirectional = :bidirectional, attributes = :attributes
+		WHERE graph_id = :graph_id AND id = :id`
+	for _, edge := range edges {
+		_, err := tx.NamedExecContext(ctx, q, edge)
+		if err != nil {
+			return fmt.Errorf("failed to update edge %s: %w", edge.ID, err)
+		}
 	}
 
-	return res, nil
+	return nil
+}
+
+// DeleteNodes ...
+func (g *GraphRepo) DeleteNodes(ctx context.Context, tx *sqlx.Tx, graphID string, nodes []postgre.Node) error {
+	if len(nodes) == 0 {
+		return nil
+	}
 
+	ids := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		ids = append(ids, node.ID)
+	}
+
+	_, err := tx.ExecContext(ctx, `DELETE FROM nodes WHERE graph_id = $1 AND id = ANY($2)`, graphID, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to delete nodes: %w", err)
+	}
+
+	return nil
 }
 
-func (g *GraphRepo) GetEdges(ctx context.Context) ([]postgre.Edge, error) {
-	var res = make([]postgre.Edge, 0)
+// DeleteEdges ...
+func (g *GraphRepo) DeleteEdges(ctx context.Context, tx *sqlx.Tx, graphID string, edges []postgre.Edge) error {
+	if len(edges) == 0 {
+		return nil
+	}
 
-	query := `select id, previous_node, next_node, cost
-		from edges;`
-	// Execute the query
-	rows, err := g.db.QueryContext(ctx, query)
+	ids := make([]string, 0, len(edges))
+	for _, edge := range edges {
+		ids = append(ids, edge.ID)
+	}
+
+	_, err := tx.ExecContext(ctx, `DELETE FROM edges WHERE graph_id = $1 AND id = ANY($2)`, graphID, pq.Array(ids))
 	if err != nil {
-		return nil, fmt.Errorf("failed to GetEdges: %w", err)
+		return fmt.Errorf("failed to delete edges: %w", err)
 	}
 
-	for rows.Next() {
-		var edge postgre.Edge
-		err := rows.Scan(&edge.ID,
-			&edge.PreviousNode,
-			&edge.NextNode,
-			&edge.Cost,
-		)
-		if err != nil {
-			return nil, err
-		}
+	return nil
+}
 
-		res = append(res, edge)
+func (g *GraphRepo) GetEdges(ctx context.Context, graphID string) ([]postgre.Edge, error) {
+	return getEdges(ctx, g.db, graphID)
+}
+
+func (g *GraphRepo) GetNodes(ctx context.Context, graphID string) ([]postgre.Node, error) {
+	return getNodes(ctx, g.db, graphID)
+}
+
+// ListGraphs returns stored graphs without nodes and edges
+func (g *GraphRepo) ListGraphs(ctx context.Context) ([]postgre.Graph, error) {
+	var res = make([]postgre.Graph, 0)
+
+	query := `select id,name
+		from graphs
+		order by id;`
+	// Execute the query
+	err := g.db.SelectContext(ctx, &res, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to ListGraphs: %w", err)
 	}
 
 	return res, nil
 }
-func (g *GraphRepo) GetNodes(ctx context.Context) ([]postgre.Node, error) {
-	var res = make([]postgre.Node, 0)
 
-	query := `select id,name, graph_id
-		from nodes;`
-	// Execute the query
-	rows, err := g.db.QueryContext(ctx, query)
+func (g *GraphRepo) GetGraph(ctx context.Context, id string) (*postgre.Graph, error) {
+	return getGraph(ctx, g.db, id, false)
+}
+
+func (g *GraphRepo) runInTransaction(ctx context.Context, exec func(tx *sqlx.Tx) error) error {
+	tx, err := g.db.BeginTxx(ctx, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to GetNodes: %w", err)
+		return err
 	}
 
-	for rows.Next() {
-		var node postgre.Node
-		err := rows.Scan(&node.ID,
-			&node.Name,
-			&node.GraphID,
-		)
-		if err != nil {
-			return nil, err
+	if err := exec(tx); err != nil {
+		rerr := tx.Rollback()
+		if rerr != nil {
+			return fmt.Errorf("transaction rollback error %w", rerr)
 		}
 
-		res = append(res, node)
+		return err
 	}
 
-	return res, nil
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("transaction commit error %w", err)
+	}
+	return nil
+
 }
 
-func (g *GraphRepo) GetGraph(ctx context.Context) (*postgre.Graph, error) {
+// getGraph reads graph with nodes and edges by DB or transaction, forUpdate locks graph row till the end of transaction
+func getGraph(ctx context.Context, q sqlx.QueryerContext, id string, forUpdate bool) (*postgre.Graph, error) {
 	var (
 		res postgre.Graph
 	)
 
 	query := `select id,name
-		from graphs;`
+		from graphs
+		where id = $1`
+	if forUpdate {
+		query += ` for update`
+	}
 	// Execute the query
-	err := g.db.GetContext(ctx, &res, query)
+	err := sqlx.GetContext(ctx, q, &res, query, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to GetGraph %s: %w", id, ErrGraphNotFound)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to GetGraph: %w", err)
 	}
 
-	res.Nodes, err = g.GetNodes(ctx)
+	res.Nodes, err = getNodes(ctx, q, id)
 	if err != nil {
 		return nil, err
 	}
 
-	res.Edges, err = g.GetEdges(ctx)
+	res.Edges, err = getEdges(ctx, q, id)
 	if err != nil {
 		return nil, err
 	}
@@ -208,36 +288,70 @@ func (g *GraphRepo) GetGraph(ctx context.Context) (*postgre.Graph, error) {
 	return &res, nil
 }
 
-func (g *GraphRepo) runInTransaction(ctx context.Context, exec func(tx *sqlx.Tx) error) error {
-	tx, err := g.db.BeginTxx(ctx, nil)
+// getEdges reads edges in ID order, paths are searched in adjacency order so paging depends on it
+func getEdges(ctx context.Context, q sqlx.QueryerContext, graphID string) ([]postgre.Edge, error) {
+	var res = make([]postgre.Edge, 0)
+
+	query := `select id, name, previous_node, next_node, cost, graph_id, bidirectional, attributes
+		from edges
+		where graph_id = $1
+		order by id;`
+	// Execute the query
+	rows, err := q.QueryContext(ctx, query, graphID)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to GetEdges: %w", err)
 	}
+	defer rows.Close()
 
-	if err := exec(tx); err != nil {
-		rerr := tx.Rollback()
-		if rerr != nil {
-			return fmt.Errorf("transaction rollback error %w", rerr)
+	for rows.Next() {
+		var edge postgre.Edge
+		err := rows.Scan(&edge.ID,
+			&edge.Name,
+			&edge.PreviousNode,
+			&edge.NextNode,
+			&edge.Cost,
+			&edge.GraphID,
+			&edge.Bidirectional,
+			&edge.Attributes,
+		)
+		if err != nil {
+			return nil, err
 		}
 
-		return err
-	}
-
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("transaction commit error %w", err)
+		res = append(res, edge)
 	}
-	return nil
 
+	return res, rows.Err()
 }
 
-func truncateTable(ctx context.Context, tx *sqlx.Tx, tableName string) error {
-	query := fmt.Sprintf("TRUNCATE TABLE %s CASCADE", tableName)
+// getNodes reads nodes in ID order
+func getNodes(ctx context.Context, q sqlx.QueryerContext, graphID string) ([]postgre.Node, error) {
+	var res = make([]postgre.Node, 0)
 
+	query := `select id,name, graph_id, attributes
+		from nodes
+		where graph_id = $1
+		order by id;`
 	// Execute the query
-	_, err := tx.ExecContext(ctx, query)
+	rows, err := q.QueryContext(ctx, query, graphID)
 	if err != nil {
-		return fmt.Errorf("failed to truncate table %s: %v", tableName, err)
+		return nil, fmt.Errorf("failed to GetNodes: %w", err)
 	}
+	defer rows.Close()
 
-	return nil
+	for rows.Next() {
+		var node postgre.Node
+		err := rows.Scan(&node.ID,
+			&node.Name,
+			&node.GraphID,
+			&node.Attributes,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, node)
+	}
+
+	return res, rows.Err()
 }
@@ -0,0 +1,176 @@
+package receiver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"graphs/entity"
+	"graphs/entity/dot"
+	jsonentity "graphs/entity/json"
+	"net/http"
+	"os"
+	"time"
+)
+
+// maxRequestBody limits size of the queries document accepted over HTTP.
+const maxRequestBody = 1 << 20
+
+type HTTPConfig struct {
+	Addr            string
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	RequestTimeout  time.Duration
+	ShutdownTimeout time.Duration
+}
+
+// ServeHTTP serves POST /queries until ctx is cancelled, then shuts the server down gracefully.
+func ServeHTTP(ctx context.Context, graphs *entity.Graphs, cfg HTTPConfig, queryCfg Config) error {
+	srv := NewHTTPServer(graphs, cfg, queryCfg)
+
+	errCh := make(chan error, 1)
+	go func() {
+		fmt.Fprintf(os.Stderr, "HTTP server listening on %s\n", cfg.Addr)
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("failed to listen %s: %w", cfg.Addr, err)
+	// part of graceful shutdown. Finish in-flight requests and exit when receive context cancelled
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shutdown HTTP server: %w", err)
+	}
+
+	if err := <-errCh; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	fmt.Fprintln(os.Stderr, "HTTP server stopped")
+
+	return nil
+}
+
+func NewHTTPServer(graphs *entity.Graphs, cfg HTTPConfig, queryCfg Config) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/queries", timeoutHandler(queriesHandler(graphs, queryCfg), cfg.RequestTimeout))
+	mux.Handle("/dot", timeoutHandler(dotHandler(graphs), cfg.RequestTimeout))
+
+	return &http.Server{
+		Addr:         cfg.Addr,
+		Handler:      mux,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+	}
+}
+
+// timeoutHandler answers with timeout error when handler runs longer than timeout, 0 means no deadline
+func timeoutHandler(h http.Handler, timeout time.Duration) http.Handler {
+	if timeout <= 0 {
+		return h
+	}
+
+	timeoutJSON := http.TimeoutHandler(h, timeout, `{"error":{"code":"timeout","message":"request deadline exceeded"}}`)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// timeout body is written as is, headers set by h replace this one when h is in time
+		w.Header().Set("Content-Type", "application/json")
+		timeoutJSON.ServeHTTP(w, r)
+	})
+}
+
+// queriesHandler accepts RequestQuery body and responds with Answer.
+func queriesHandler(graphs *entity.Graphs, cfg Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeError(w, http.StatusMethodNotAllowed, queryError(jsonentity.ErrCodeInvalidRequest, "method not allowed"))
+			return
+		}
+
+		var requestQuery = jsonentity.RequestQuery{}
+		dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBody))
+		if err := dec.Decode(&requestQuery); err != nil {
+			writeError(w, http.StatusBadRequest, queryError(jsonentity.ErrCodeInvalidRequest, "%v", err))
+			return
+		}
+
+		// request context is cancelled by timeout handler or client disconnect
+		answer, err := AnswerRequest(r.Context(), graphs, &requestQuery, cfg)
+		if err != nil {
+			var (
+				status = http.StatusBadRequest
+				qerr   *jsonentity.QueryError
+			)
+			if !errors.As(err, &qerr) {
+				qerr = queryError(jsonentity.ErrCodeInternal, "%v", err)
+			}
+			if qerr.Code == jsonentity.ErrCodeUnknownGraph {
+				status = http.StatusNotFound
+			}
+
+			writeError(w, status, qerr)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, answer)
+	}
+}
+
+// dotHandler renders graph in Graphviz DOT format, GET /dot?graph=g0&start=a&end=d highlights the cheapest path.
+func dotHandler(graphs *entity.Graphs) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			writeError(w, http.StatusMethodNotAllowed, queryError(jsonentity.ErrCodeInvalidRequest, "method not allowed"))
+			return
+		}
+
+		params := r.URL.Query()
+
+		graph, ok := graphs.Get(params.Get("graph"))
+		if !ok {
+			writeError(w, http.StatusNotFound, queryError(jsonentity.ErrCodeUnknownGraph, "graph %q not found", params.Get("graph")))
+			return
+		}
+
+		var highlight dot.Highlight
+		if start, end := params.Get("start"), params.Get("end"); start != "" && end != "" {
+			path, err := graph.GetCheapestPaths(r.Context(), start, end, entity.Filter{})
+			if err != nil {
+				writeError(w, http.StatusServiceUnavailable, searchError(graph, err, jsonentity.VersionLegacy))
+				return
+			}
+
+			if path != nil {
+				highlight.Path = path.Path
+				highlight.Edges = path.Edges
+			}
+		}
+
+		w.Header().Set("Content-Type", "text/vnd.graphviz")
+		if err := dot.EncodeGraph(w, graph, highlight); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write HTTP response: %v\n", err)
+		}
+	}
+}
+
+// writeError answers with the same error document as NDJSON receiver
+func writeError(w http.ResponseWriter, status int, qerr *jsonentity.QueryError) {
+	writeJSON(w, status, jsonentity.ErrorAnswer{Error: *qerr})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write HTTP response: %v\n", err)
+	}
+}
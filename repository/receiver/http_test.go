@@ -0,0 +1,129 @@
+package receiver
+
+import (
+	"encoding/json"
+	"graphs/entity"
+	jsonentity "graphs/entity/json"
+	"graphs/entity/postgre"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testGraphs() *entity.Graphs {
+	return entity.NewGraphs("g0", []postgre.Graph{{
+		ID:    "g0",
+		Name:  "test",
+		Nodes: []postgre.Node{{ID: "a"}, {ID: "b"}},
+		Edges: []postgre.Edge{{ID: "a1", PreviousNode: "a", NextNode: "b", Cost: 1}},
+	}})
+}
+
+func TestQueriesHandler(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		body    string
+		timeout time.Duration
+		status  int
+		code    string // error code, empty if request is answered
+	}{
+		{name: "answer", method: http.MethodPost, body: `{"queries":[{"cheapest":{"start":"a","end":"b"}}]}`, status: http.StatusOK},
+		{name: "no request timeout", method: http.MethodPost, body: `{"queries":[]}`, status: http.StatusOK},
+		{name: "request timeout", method: http.MethodPost, body: `{"queries":[]}`, timeout: time.Minute, status: http.StatusOK},
+		{name: "method", method: http.MethodGet, status: http.StatusMethodNotAllowed, code: jsonentity.ErrCodeInvalidRequest},
+		{name: "invalid JSON", method: http.MethodPost, body: `{"queries":`, status: http.StatusBadRequest, code: jsonentity.ErrCodeInvalidRequest},
+		{name: "unsupported version", method: http.MethodPost, body: `{"version":7,"queries":[]}`, status: http.StatusBadRequest, code: jsonentity.ErrCodeInvalidRequest},
+		{name: "unknown graph", method: http.MethodPost, body: `{"graph":"g1","queries":[]}`, status: http.StatusNotFound, code: jsonentity.ErrCodeUnknownGraph},
+		{name: "body limit", method: http.MethodPost, body: `{"queries":[` + strings.Repeat(`{},`, maxRequestBody/3) + `{}]}`, status: http.StatusBadRequest, code: jsonentity.ErrCodeInvalidRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := NewHTTPServer(testGraphs(), HTTPConfig{RequestTimeout: tt.timeout}, Config{})
+
+			rec := httptest.NewRecorder()
+			srv.Handler.ServeHTTP(rec, httptest.NewRequest(tt.method, "/queries", strings.NewReader(tt.body)))
+
+			if rec.Code != tt.status {
+				t.Errorf("expected status %d, got %d: %s", tt.status, rec.Code, rec.Body)
+			}
+
+			if tt.status == http.StatusMethodNotAllowed && rec.Header().Get("Allow") != http.MethodPost {
+				t.Errorf("expected Allow %s, got %q", http.MethodPost, rec.Header().Get("Allow"))
+			}
+
+			if tt.code == "" {
+				return
+			}
+
+			var answer jsonentity.ErrorAnswer
+			if err := json.Unmarshal(rec.Body.Bytes(), &answer); err != nil || answer.Error.Code != tt.code || answer.Error.Message == "" {
+				t.Errorf("expected %s error, got %s", tt.code, rec.Body)
+			}
+		})
+	}
+}
+
+func TestQueriesHandlerTimeout(t *testing.T) {
+	// complete graph has too many simple paths to enumerate them in time
+	graph := postgre.Graph{ID: "g0"}
+	for i := 0; i < 20; i++ {
+		graph.Nodes = append(graph.Nodes, postgre.Node{ID: strconv.Itoa(i)})
+		for j := 0; j < 20; j++ {
+			if i != j {
+				graph.Edges = append(graph.Edges, postgre.Edge{ID: strconv.Itoa(i) + "-" + strconv.Itoa(j), PreviousNode: strconv.Itoa(i), NextNode: strconv.Itoa(j), Cost: 1})
+			}
+		}
+	}
+	srv := NewHTTPServer(entity.NewGraphs("g0", []postgre.Graph{graph}), HTTPConfig{RequestTimeout: 50 * time.Millisecond}, Config{})
+
+	rec := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/queries", strings.NewReader(`{"queries":[{"paths":{"start":"0","end":"19"}}]}`)))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status %d, got %d: %s", http.StatusServiceUnavailable, rec.Code, rec.Body)
+	}
+
+	if contentType := rec.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("expected application/json, got %q", contentType)
+	}
+
+	var answer jsonentity.ErrorAnswer
+	if err := json.Unmarshal(rec.Body.Bytes(), &answer); err != nil || answer.Error.Code != jsonentity.ErrCodeTimeout {
+		t.Errorf("expected %s error, got %s", jsonentity.ErrCodeTimeout, rec.Body)
+	}
+
+	// answer in time keeps content type of the handler
+	rec = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dot", nil))
+
+	if contentType := rec.Header().Get("Content-Type"); rec.Code != http.StatusOK || contentType == "application/json" {
+		t.Errorf("expected DOT answer, got %d %q", rec.Code, contentType)
+	}
+}
+
+func TestDotHandlerErrors(t *testing.T) {
+	srv := NewHTTPServer(testGraphs(), HTTPConfig{}, Config{})
+
+	for _, tt := range []struct {
+		method string
+		target string
+		status int
+		code   string
+	}{
+		{method: http.MethodPost, target: "/dot", status: http.StatusMethodNotAllowed, code: jsonentity.ErrCodeInvalidRequest},
+		{method: http.MethodGet, target: "/dot?graph=g1", status: http.StatusNotFound, code: jsonentity.ErrCodeUnknownGraph},
+	} {
+		rec := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.target, nil))
+
+		var answer jsonentity.ErrorAnswer
+		if err := json.Unmarshal(rec.Body.Bytes(), &answer); err != nil || rec.Code != tt.status || answer.Error.Code != tt.code {
+			t.Errorf("%s %s: expected %d %s error, got %d %s", tt.method, tt.target, tt.status, tt.code, rec.Code, rec.Body)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package receiver
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"graphs/entity"
+	jsonentity "graphs/entity/json"
+	"io"
+	"sync"
+)
+
+// ReceiveNDJSON reads newline-delimited RequestQuery documents from r and writes one compact Answer line per request to w.
+// With stream every query answer is written as StreamAnswer line as soon as it is evaluated.
+// Returns nil on EOF or when ctx is cancelled.
+func ReceiveNDJSON(ctx context.Context, r io.Reader, w io.Writer, graphs *entity.Graphs, cfg Config, stream bool) error {
+	var (
+		lines = make(chan []byte)
+		// reading is not cancellable, reader goroutine is left blocked on shutdown
+		readErr = make(chan error, 1)
+		out     = &ndjsonWriter{enc: json.NewEncoder(w)}
+	)
+
+	go func() {
+		defer close(lines)
+
+		br := bufio.NewReader(r)
+		for {
+			line, err := br.ReadBytes('\n')
+			if len(bytes.TrimSpace(line)) > 0 {
+				select {
+				case lines <- line:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			if err != nil {
+				if !errors.Is(err, io.EOF) {
+					readErr <- err
+				}
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		// part of graceful shutdown. Do current and exit when receive context cancelled
+		case <-ctx.Done():
+			return nil
+		case line, ok := <-lines:
+			if !ok {
+				select {
+				case err := <-readErr:
+					return fmt.Errorf("failed to read queries: %w", err)
+				default:
+					return nil
+				}
+			}
+
+			if err := answerLine(ctx, line, out, graphs, cfg, stream); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// answerLine answers a single request line, only write errors are returned
+func answerLine(ctx context.Context, line []byte, out *ndjsonWriter, graphs *entity.Graphs, cfg Config, stream bool) error {
+	var requestQuery = jsonentity.RequestQuery{}
+	if err := json.Unmarshal(line, &requestQuery); err != nil {
+		return out.write(jsonentity.ErrorAnswer{Error: jsonentity.QueryError{Code: jsonentity.ErrCodeInvalidRequest, Message: err.Error()}})
+	}
+
+	graph, qerr := prepareRequest(graphs, &requestQuery)
+	if qerr != nil {
+		return out.write(jsonentity.ErrorAnswer{Error: *qerr})
+	}
+
+	if !stream {
+		return out.write(GetAnswer(ctx, graph, &requestQuery, cfg))
+	}
+
+	// write error is kept by writer and returned after all queries
+	evaluate(ctx, graph, &requestQuery, cfg, func(i int, answer jsonentity.QueryAnswer) {
+		_ = out.write(jsonentity.StreamAnswer{Graph: graph.ID, Index: i, QueryAnswer: answer})
+	})
+
+	return out.error()
+}
+
+// ndjsonWriter writes one JSON document per line, safe for concurrent use
+type ndjsonWriter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	err error
+}
+
+// write encodes v as a line, the first write error is kept and returned for all next writes
+func (w *ndjsonWriter) write(v interface{}) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.err == nil {
+		if err := w.enc.Encode(v); err != nil {
+			w.err = fmt.Errorf("failed to write answer: %w", err)
+		}
+	}
+
+	return w.err
+}
+
+func (w *ndjsonWriter) error() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.err
+}
@@ -0,0 +1,96 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	jsonentity "graphs/entity/json"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// ndjsonLines splits output into lines, every line must be a single JSON document
+func ndjsonLines(t *testing.T, out string) []string {
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	for _, line := range lines {
+		if !json.Valid([]byte(line)) {
+			t.Fatalf("expected JSON line, got %q", line)
+		}
+	}
+
+	return lines
+}
+
+func TestReceiveNDJSON(t *testing.T) {
+	in := strings.NewReader(`{"queries":[{"cheapest":{"start":"a","end":"b"}}]}
+{"queries":
+
+{"graph":"g1","queries":[]}
+{"queries":[{"cheapest":{"start":"b","end":"a"}}]}`)
+
+	var out bytes.Buffer
+	if err := ReceiveNDJSON(context.Background(), in, &out, testGraphs(), Config{}, false); err != nil {
+		t.Fatalf("expected clean return on EOF, got %v", err)
+	}
+
+	lines := ndjsonLines(t, out.String())
+	if len(lines) != 4 {
+		t.Fatalf("expected one line per request, got %d:\n%s", len(lines), out.String())
+	}
+
+	var answer jsonentity.Answer
+	if err := json.Unmarshal([]byte(lines[0]), &answer); err != nil || len(answer.Answers) != 1 || answer.Answers[0].Cheapest == nil {
+		t.Errorf("expected cheapest answer, got %s", lines[0])
+	}
+
+	for i, code := range map[int]string{1: jsonentity.ErrCodeInvalidRequest, 2: jsonentity.ErrCodeUnknownGraph} {
+		var errAnswer jsonentity.ErrorAnswer
+		if err := json.Unmarshal([]byte(lines[i]), &errAnswer); err != nil || errAnswer.Error.Code != code {
+			t.Errorf("line %d: expected %s error, got %s", i, code, lines[i])
+		}
+	}
+
+	// request after invalid ones is still answered
+	answer = jsonentity.Answer{}
+	if err := json.Unmarshal([]byte(lines[3]), &answer); err != nil || len(answer.Answers) != 1 || answer.Answers[0].Cheapest == nil {
+		t.Errorf("expected cheapest answer, got %s", lines[3])
+	}
+}
+
+func TestReceiveNDJSONStream(t *testing.T) {
+	in := strings.NewReader(`{"queries":[{"id":"q0","cheapest":{"start":"a","end":"b"}},{"id":"q1"},{"id":"q2","paths":{"start":"a","end":"b"}}]}` + "\n")
+
+	var out bytes.Buffer
+	if err := ReceiveNDJSON(context.Background(), in, &out, testGraphs(), Config{Workers: 2}, true); err != nil {
+		t.Fatalf("expected clean return on EOF, got %v", err)
+	}
+
+	lines := ndjsonLines(t, out.String())
+	if len(lines) != 3 {
+		t.Fatalf("expected one line per query, got %d:\n%s", len(lines), out.String())
+	}
+
+	answers := make([]jsonentity.StreamAnswer, 0, len(lines))
+	for _, line := range lines {
+		var a jsonentity.StreamAnswer
+		if err := json.Unmarshal([]byte(line), &a); err != nil {
+			t.Fatalf("unexpected line %s: %v", line, err)
+		}
+		answers = append(answers, a)
+	}
+
+	// answers are written as soon as ready, so in any order
+	sort.Slice(answers, func(i, j int) bool { return answers[i].Index < answers[j].Index })
+
+	for i, a := range answers {
+		if a.Index != i || a.ID != "q"+strconv.Itoa(i) || a.Graph != "g0" {
+			t.Errorf("expected answer %d of q%d in g0, got %+v", i, i, a)
+		}
+	}
+
+	if answers[0].Cheapest == nil || answers[1].Error == nil || answers[2].Paths == nil {
+		t.Errorf("expected cheapest, error and paths answers, got %+v", answers)
+	}
+}
@@ -3,16 +3,34 @@ package receiver
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"graphs/constant"
 	"graphs/entity"
 	jsonentity "graphs/entity/json"
 	"io"
+	"math"
 	"os"
+	"runtime"
+	"slices"
 	"sync"
+	"time"
 )
 
+// Config limits queries evaluation
+type Config struct {
+	Workers        int           // queries of a request evaluated concurrently, number of CPUs if 0
+	QueryTimeout   time.Duration // deadline of a single query, no deadline if 0
+	RequestTimeout time.Duration // deadline of all queries in a request, no deadline if 0
+}
+
 // Receive receives purchase Subscriptions.
-func Receive(ctx context.Context, graph *entity.Graph) {
+func Receive(ctx context.Context, graphs *entity.Graphs, cfg Config) {
+	receive(ctx, os.Stdin, os.Stdout, graphs, cfg)
+}
+
+// receive answers requests read from r with prompt, returns on EOF of r or when ctx is cancelled
+func receive(ctx context.Context, r io.Reader, w io.Writer, graphs *entity.Graphs, cfg Config) {
 	for {
 		select {
 		// part of graceful shutdown. Do current and exit when receive context cancelled
@@ -20,124 +38,776 @@ func Receive(ctx context.Context, graph *entity.Graph) {
 			return
 		default:
 			var requestQuery = jsonentity.RequestQuery{}
-			dec := json.NewDecoder(os.Stdin)
-			fmt.Print("> ")
+			dec := json.NewDecoder(r)
+			fmt.Fprint(w, "> ")
 
 			err := dec.Decode(&requestQuery)
 			if err == io.EOF {
-				continue
+				// input is closed, nothing more to answer
+				fmt.Fprintln(w)
+				return
 			}
+
+			var answer any
 			if err != nil {
-				fmt.Printf("%v\n", err)
-				continue
+				answer = jsonentity.ErrorAnswer{Error: jsonentity.QueryError{Code: jsonentity.ErrCodeInvalidRequest, Message: err.Error()}}
+			} else if graph, qerr := prepareRequest(graphs, &requestQuery); qerr != nil {
+				// request which can't be evaluated is answered the same way as over HTTP
+				answer = jsonentity.ErrorAnswer{Error: *qerr}
+			} else {
+				answer = GetAnswer(ctx, graph, &requestQuery, cfg)
 			}
 
-			answer := GetAnswer(graph, &requestQuery)
-
 			janswer, err := json.MarshalIndent(answer, "", " ")
 			if err != nil {
-				fmt.Printf("%v\n", err)
+				fmt.Fprintf(w, "%v\n", err)
 				continue
 			}
 			// print answer
-			fmt.Println(string(janswer))
+			fmt.Fprintln(w, string(janswer))
 		}
 	}
 }
 
-func GetAnswer(graph *entity.Graph, query *jsonentity.RequestQuery) *jsonentity.Answer {
+// AnswerRequest answers queries on the graph named in request.
+// Request which can't be evaluated is reported by *jsonentity.QueryError
+func AnswerRequest(ctx context.Context, graphs *entity.Graphs, query *jsonentity.RequestQuery, cfg Config) (*jsonentity.Answer, error) {
+	graph, err := prepareRequest(graphs, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return GetAnswer(ctx, graph, query, cfg), nil
+}
+
+// prepareRequest checks request and returns graph named in it
+func prepareRequest(graphs *entity.Graphs, query *jsonentity.RequestQuery) (*entity.Graph, *jsonentity.QueryError) {
+	if query.Version != 0 && query.Version != jsonentity.VersionLegacy && query.Version != jsonentity.VersionTyped {
+		return nil, queryError(jsonentity.ErrCodeInvalidRequest, "unsupported protocol version %d", query.Version)
+	}
+
+	graph, ok := graphs.Get(query.Graph)
+	if !ok {
+		return nil, queryError(jsonentity.ErrCodeUnknownGraph, "graph %q not found", query.Graph)
+	}
+
+	return graph, nil
+}
+
+// GetAnswer evaluates queries concurrently by cfg.Workers goroutines, answers are in queries order
+func GetAnswer(ctx context.Context, graph *entity.Graph, query *jsonentity.RequestQuery, cfg Config) *jsonentity.Answer {
+	var res = jsonentity.Answer{Version: query.Version, Graph: graph.ID, Answers: make([]jsonentity.QueryAnswer, len(query.Queries))}
+
+	// every query answer is written by one worker only
+	evaluate(ctx, graph, query, cfg, func(i int, answer jsonentity.QueryAnswer) {
+		res.Answers[i] = answer
+	})
+
+	return &res
+}
+
+// evaluate runs queries by cfg.Workers goroutines and calls onAnswer with query index as soon as query is answered.
+// onAnswer is called concurrently. Workers left by a request of fewer queries are shared by queries searching concurrently,
+// so a request never runs more than cfg.Workers searches at once.
+func evaluate(ctx context.Context, graph *entity.Graph, query *jsonentity.RequestQuery, cfg Config, onAnswer func(int, jsonentity.QueryAnswer)) {
+	var (
+		wg      sync.WaitGroup
+		indexes = make(chan int, len(query.Queries))
+		workers = cfg.Workers
+	)
+
+	if len(query.Queries) == 0 {
+		return
+	}
+
+	ctx, cancel := withTimeout(ctx, cfg.RequestTimeout)
+	defer cancel()
+
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	for i := range query.Queries {
+		indexes <- i
+	}
+	close(indexes)
+
+	var (
+		goroutines = min(workers, len(query.Queries))
+		queryCfg   = cfg
+	)
+	queryCfg.Workers = max(1, workers/goroutines)
+
+	for w := 0; w < goroutines; w++ {
+		wg.Add(1)
+		// make worker goroutine for concurrently search in graph
+		go func() {
+			defer wg.Done()
+
+			for i := range indexes {
+				onAnswer(i, answerQueryWithTimeout(ctx, graph, query.Queries[i], query.Version, queryCfg))
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+func GetAnswerIterate(ctx context.Context, graph *entity.Graph, query *jsonentity.RequestQuery, cfg Config) *jsonentity.Answer {
+
 	var (
-		res = jsonentity.Answer{Answers: make([]map[string]jsonentity.PathResponse, 0, len(query.Queries))}
-		wg  sync.WaitGroup
-		//make buffered channel for wait response in concurrency
-		ch = make(chan map[string]jsonentity.PathResponse, len(query.Queries))
-		pw = 0
+		res = jsonentity.Answer{Version: query.Version, Graph: graph.ID, Answers: make([]jsonentity.QueryAnswer, 0, len(query.Queries))}
 	)
 
 	if len(query.Queries) == 0 {
 		return &res
 	}
 
+	ctx, cancel := withTimeout(ctx, cfg.RequestTimeout)
+	defer cancel()
+
 	for _, q := range query.Queries {
-		if q.Cheapest != nil && q.Cheapest.Start != "" && q.Cheapest.End != "" {
-			wg.Add(1)
-			pw++
-			// make goroutine with result in channel for concurrently search path in graph
-			go func(resultChannel chan<- map[string]jsonentity.PathResponse, start, end string) {
-				defer wg.Done()
-				r := jsonentity.PathResponse{From: start, To: end, Path: false}
+		res.Answers = append(res.Answers, answerQueryWithTimeout(ctx, graph, q, query.Version, cfg))
+	}
+
+	return &res
+}
+
+func answerQueryWithTimeout(ctx context.Context, graph *entity.Graph, q jsonentity.Query, version int, cfg Config) jsonentity.QueryAnswer {
+	ctx, cancel := withTimeout(ctx, cfg.QueryTimeout)
+	defer cancel()
+
+	return answerQuery(ctx, graph, q, version, cfg)
+}
+
+// withTimeout sets ctx deadline, zero timeout means no deadline
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, timeout)
+}
+
+// answerQuery runs every query type set in query, paths are in shape of protocol version.
+// Invalid or timed out query is answered with error only, matrix origins are searched by cfg.Workers goroutines.
+func answerQuery(ctx context.Context, graph *entity.Graph, q jsonentity.Query, version int, cfg Config) jsonentity.QueryAnswer {
+	if err := validateQuery(graph, q); err != nil {
+		return jsonentity.QueryAnswer{ID: q.ID, Error: err}
+	}
+
+	var (
+		a   = jsonentity.QueryAnswer{ID: q.ID}
+		err error
+	)
+
+	if q.Cheapest != nil && err == nil {
+		a.Cheapest, err = getCheapest(ctx, graph, *q.Cheapest, version)
+	}
+
+	if q.Paths != nil && err == nil {
+		a.Paths, err = getPaths(ctx, graph, *q.Paths, version)
+	}
+
+	if q.TopK != nil && err == nil {
+		a.TopK, err = getTopK(ctx, graph, *q.TopK, version)
+	}
+
+	if q.Cycles != nil && err == nil {
+		a.Cycles, err = getCycles(ctx, graph, q.Cycles.Limit, version)
+	}
+
+	if q.SCC != nil && err == nil {
+		a.SCC, err = getSCC(ctx, graph, *q.SCC)
+	}
+
+	if q.Reachable != nil && err == nil {
+		a.Reachable, err = getReachable(ctx, *q.Reachable, graph.Reachable)
+	}
 
-				pa := graph.GetCheapestPaths(start, end)
+	if q.Ancestors != nil && err == nil {
+		a.Ancestors, err = getReachable(ctx, *q.Ancestors, graph.Ancestors)
+	}
+
+	if q.Neighbourhood != nil && err == nil {
+		a.Neighbourhood, err = getNeighbourhood(ctx, graph, *q.Neighbourhood)
+	}
+
+	if q.SSSP != nil && err == nil {
+		a.SSSP, err = getSSSP(ctx, graph, *q.SSSP)
+	}
 
-				if len(pa) > 0 {
-					r.Path = pa
-				}
+	if q.Matrix != nil && err == nil {
+		a.Matrix, err = getMatrix(ctx, graph, *q.Matrix, cfg.Workers, version)
+	}
+
+	if q.Topo != nil && err == nil {
+		a.Topo, err = getTopo(ctx, graph, version)
+	}
+
+	if q.CriticalPath != nil && err == nil {
+		a.CriticalPath, err = getCriticalPath(ctx, graph, version)
+	}
+
+	if err != nil {
+		return jsonentity.QueryAnswer{ID: q.ID, Error: searchError(graph, err, version)}
+	}
+
+	return a
+}
+
+// searchError reports search stopped by deadline or cancellation, or by negative cycle of the graph
+func searchError(graph *entity.Graph, err error, version int) *jsonentity.QueryError {
+	var nc *entity.NegativeCycleError
+	if errors.As(err, &nc) {
+		qErr := queryError(jsonentity.ErrCodeNegativeCycle, "%v", err)
+		cycle := makeCycle(graph, nc.Cycle, version)
+		qErr.Cycle = &cycle
+
+		return qErr
+	}
+
+	var be *entity.BidirectionalEdgeError
+	if errors.As(err, &be) {
+		return queryError(jsonentity.ErrCodeBidirectionalEdge, "%v", err)
+	}
 
-				resultChannel <- map[string]jsonentity.PathResponse{"cheapest": r}
-			}(ch, q.Cheapest.Start, q.Cheapest.End)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return queryError(jsonentity.ErrCodeTimeout, "query deadline exceeded")
+	}
+
+	if errors.Is(err, context.Canceled) {
+		return queryError(jsonentity.ErrCodeCanceled, "query canceled")
+	}
+
+	return queryError(jsonentity.ErrCodeInternal, "%v", err)
+}
+
+// validateQuery returns the first problem found in query
+func validateQuery(graph *entity.Graph, q jsonentity.Query) *jsonentity.QueryError {
+	if q.Cheapest == nil && q.Paths == nil && q.TopK == nil && q.Cycles == nil && q.SCC == nil &&
+		q.Topo == nil && q.CriticalPath == nil && q.Reachable == nil && q.Ancestors == nil && q.Neighbourhood == nil &&
+		q.SSSP == nil && q.Matrix == nil {
+		return queryError(jsonentity.ErrCodeEmptyQuery, "query has no known query type")
+	}
+
+	if q.Cheapest != nil {
+		if err := validateEnds(graph, "cheapest", q.Cheapest.Start, q.Cheapest.End); err != nil {
+			return err
 		}
 
-		if q.Paths != nil && q.Paths.Start != "" && q.Paths.End != "" {
-			wg.Add(1)
-			pw++
-			// make goroutine with result in channel for concurrently search path in graph
-			go func(resultChannel chan<- map[string]jsonentity.PathResponse, start, end string) {
-				defer wg.Done()
-				r := jsonentity.PathResponse{From: start, To: end, Paths: make([]string, 0)}
+		if q.Cheapest.Limit != 0 || q.Cheapest.Offset != 0 || q.Cheapest.MaxDepth != 0 || q.Cheapest.MaxCost != 0 {
+			return queryError(jsonentity.ErrCodeInvalidQuery, "cheapest: limit, offset, max_depth and max_cost are supported by paths query only")
+		}
 
-				pa := graph.GetPaths(start, end)
-				if len(pa) > 0 {
-					r.Paths = pa
-				}
+		if err := validateVia(graph, *q.Cheapest); err != nil {
+			return err
+		}
 
-				resultChannel <- map[string]jsonentity.PathResponse{"paths": r}
-			}(ch, q.Paths.Start, q.Paths.End)
+		if err := validateFilter("cheapest", q.Cheapest.Filter); err != nil {
+			return err
 		}
 	}
 
-	wg.Wait()
+	if q.Paths != nil {
+		if err := validateEnds(graph, "paths", q.Paths.Start, q.Paths.End); err != nil {
+			return err
+		}
+
+		if q.Paths.Limit < 0 || q.Paths.Offset < 0 || q.Paths.MaxDepth < 0 || q.Paths.MaxCost < 0 {
+			return queryError(jsonentity.ErrCodeInvalidQuery, "paths: limit, offset, max_depth and max_cost must not be negative")
+		}
 
-	for i := 0; i < pw; i++ {
-		res.Answers = append(res.Answers, <-ch)
+		if len(q.Paths.Via) > 0 || q.Paths.ViaAnyOrder {
+			return queryError(jsonentity.ErrCodeInvalidQuery, "paths: via is supported by cheapest query only")
+		}
+
+		if err := validateFilter("paths", q.Paths.Filter); err != nil {
+			return err
+		}
 	}
 
-	return &res
+	if q.TopK != nil {
+		if err := validateEnds(graph, "top_k", q.TopK.Start, q.TopK.End); err != nil {
+			return err
+		}
+
+		if q.TopK.K <= 0 {
+			return queryError(jsonentity.ErrCodeInvalidQuery, "top_k: k must be greater than 0")
+		}
+
+		if err := validateFilter("top_k", q.TopK.Filter); err != nil {
+			return err
+		}
+	}
+
+	if q.Reachable != nil {
+		if err := validateNode(graph, "reachable", q.Reachable.Node, q.Reachable.Filter); err != nil {
+			return err
+		}
+	}
+
+	if q.Ancestors != nil {
+		if err := validateNode(graph, "ancestors", q.Ancestors.Node, q.Ancestors.Filter); err != nil {
+			return err
+		}
+	}
+
+	if q.Neighbourhood != nil {
+		if err := validateNode(graph, "neighbourhood", q.Neighbourhood.Node, q.Neighbourhood.Filter); err != nil {
+			return err
+		}
+
+		if q.Neighbourhood.MaxHops < 0 || q.Neighbourhood.MaxCost < 0 {
+			return queryError(jsonentity.ErrCodeInvalidQuery, "neighbourhood: max_hops and max_cost must not be negative")
+		}
+	}
+
+	if q.SSSP != nil {
+		if err := validateNode(graph, "sssp", q.SSSP.Origin, q.SSSP.Filter); err != nil {
+			return err
+		}
+	}
+
+	if q.Matrix != nil {
+		if err := validateMatrix(graph, *q.Matrix); err != nil {
+			return err
+		}
+	}
+
+	if q.Cycles != nil && q.Cycles.Limit < 0 {
+		return queryError(jsonentity.ErrCodeInvalidQuery, "cycles: limit must not be negative")
+	}
+
+	return nil
+}
+
+func validateEnds(graph *entity.Graph, queryType, start, end string) *jsonentity.QueryError {
+	if start == "" || end == "" {
+		return queryError(jsonentity.ErrCodeInvalidQuery, "%s: start and end are required", queryType)
+	}
+
+	for _, n := range []string{start, end} {
+		if !graph.HasNode(n) {
+			return queryError(jsonentity.ErrCodeUnknownNode, "%s: node %q not found", queryType, n)
+		}
+	}
+
+	return nil
 }
 
-func GetAnswerIterate(graph *entity.Graph, query *jsonentity.RequestQuery) *jsonentity.Answer {
+// validateNode checks single node queries
+func validateNode(graph *entity.Graph, queryType, node string, filter *jsonentity.Filter) *jsonentity.QueryError {
+	if node == "" {
+		return queryError(jsonentity.ErrCodeInvalidQuery, "%s: node is required", queryType)
+	}
+
+	if !graph.HasNode(node) {
+		return queryError(jsonentity.ErrCodeUnknownNode, "%s: node %q not found", queryType, node)
+	}
+
+	return validateFilter(queryType, filter)
+}
+
+func validateMatrix(graph *entity.Graph, q jsonentity.MatrixQuery) *jsonentity.QueryError {
+	if len(q.Origins) == 0 {
+		return queryError(jsonentity.ErrCodeInvalidQuery, "matrix: origins are required")
+	}
+
+	for _, n := range append(slices.Clone(q.Origins), q.Destinations...) {
+		if !graph.HasNode(n) {
+			return queryError(jsonentity.ErrCodeUnknownNode, "matrix: node %q not found", n)
+		}
+	}
+
+	return validateFilter("matrix", q.Filter)
+}
+
+func validateVia(graph *entity.Graph, q jsonentity.PathQuery) *jsonentity.QueryError {
+	for _, n := range q.Via {
+		if !graph.HasNode(n) {
+			return queryError(jsonentity.ErrCodeUnknownNode, "cheapest: via node %q not found", n)
+		}
+	}
+
+	if q.ViaAnyOrder && len(q.Via) > entity.MaxUnorderedVia {
+		return queryError(jsonentity.ErrCodeInvalidQuery, "cheapest: at most %d via nodes in any order", entity.MaxUnorderedVia)
+	}
+
+	return nil
+}
+
+// validateFilter checks every predicate has something to match and a known operator
+func validateFilter(queryType string, f *jsonentity.Filter) *jsonentity.QueryError {
+	if f == nil {
+		return nil
+	}
+
+	for _, predicates := range [][]jsonentity.Predicate{f.IncludeNodes, f.ExcludeNodes, f.IncludeEdges, f.ExcludeEdges} {
+		for _, p := range predicates {
+			if len(p.IDs) == 0 && p.Attribute == "" {
+				return queryError(jsonentity.ErrCodeInvalidQuery, "%s: filter predicate must have ids or attribute", queryType)
+			}
+
+			if !entity.ValidOp(p.Op) {
+				return queryError(jsonentity.ErrCodeInvalidQuery, "%s: unknown filter op %q", queryType, p.Op)
+			}
+
+			switch p.Value.(type) {
+			case string, float64, bool, nil:
+			default:
+				return queryError(jsonentity.ErrCodeInvalidQuery, "%s: filter value must be a string, number or bool", queryType)
+			}
+
+			_, number := p.Value.(float64)
+			switch {
+			case p.Attribute == "" || p.Op == entity.OpExists:
+			case (p.Op == entity.OpLt || p.Op == entity.OpLte || p.Op == entity.OpGt || p.Op == entity.OpGte) && !number:
+				return queryError(jsonentity.ErrCodeInvalidQuery, "%s: filter op %q needs a number value", queryType, p.Op)
+			case p.Value == nil:
+				return queryError(jsonentity.ErrCodeInvalidQuery, "%s: filter attribute %q needs a value", queryType, p.Attribute)
+			}
+		}
+	}
+
+	return nil
+}
+
+// makeFilter maps query filter on search filter, nil filter allows everything
+func makeFilter(f *jsonentity.Filter) entity.Filter {
+	if f == nil {
+		return entity.Filter{}
+	}
+
+	return entity.Filter{
+		IncludeNodes: makePredicates(f.IncludeNodes),
+		ExcludeNodes: makePredicates(f.ExcludeNodes),
+		IncludeEdges: makePredicates(f.IncludeEdges),
+		ExcludeEdges: makePredicates(f.ExcludeEdges),
+	}
+}
+
+func makePredicates(ps []jsonentity.Predicate) []entity.Predicate {
+	predicates := make([]entity.Predicate, 0, len(ps))
+	for _, p := range ps {
+		predicates = append(predicates, entity.Predicate{IDs: p.IDs, Attribute: p.Attribute, Op: p.Op, Value: p.Value})
+	}
+
+	return predicates
+}
+
+func queryError(code, format string, args ...interface{}) *jsonentity.QueryError {
+	return &jsonentity.QueryError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+func getCheapest(ctx context.Context, graph *entity.Graph, q jsonentity.PathQuery, version int) (*jsonentity.PathResponse, error) {
+	r := jsonentity.PathResponse{From: q.Start, To: q.End, Path: false}
 
 	var (
-		res = jsonentity.Answer{Answers: make([]map[string]jsonentity.PathResponse, 0, len(query.Queries))}
+		pa  *entity.PathsCost
+		err error
 	)
 
-	if len(query.Queries) == 0 {
-		return &res
+	if len(q.Via) > 0 {
+		pa, err = graph.GetCheapestPathVia(ctx, q.Start, q.End, q.Via, q.ViaAnyOrder, makeFilter(q.Filter))
+	} else {
+		pa, err = graph.GetCheapestPaths(ctx, q.Start, q.End, makeFilter(q.Filter))
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	for _, q := range query.Queries {
-		if q.Cheapest != nil && q.Cheapest.Start != "" && q.Cheapest.End != "" {
-			r := jsonentity.PathResponse{From: q.Cheapest.Start, To: q.Cheapest.End, Path: false}
+	if pa != nil {
+		r.Path = makePath(graph, *pa, version)
+	}
 
-			pa := graph.GetCheapestPaths(q.Cheapest.Start, q.Cheapest.End)
+	return &r, nil
+}
+
+func getPaths(ctx context.Context, graph *entity.Graph, q jsonentity.PathQuery, version int) (*jsonentity.PathResponse, error) {
+	r := jsonentity.PathResponse{From: q.Start, To: q.End, Paths: make([]string, 0)}
+
+	pa, truncated, err := graph.GetPaths(ctx, q.Start, q.End, entity.PathsLimit{
+		Limit:    q.Limit,
+		Offset:   q.Offset,
+		MaxDepth: q.MaxDepth,
+		MaxCost:  q.MaxCost,
+	}, makeFilter(q.Filter))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pa) > 0 {
+		r.Paths = makePaths(graph, pa, version)
+	}
+
+	if truncated {
+		r.Truncated = true
+		r.NextOffset = q.Offset + len(pa)
+	}
+
+	return &r, nil
+}
 
-			if len(pa) > 0 {
-				r.Path = pa
+func getTopK(ctx context.Context, graph *entity.Graph, q jsonentity.TopKQuery, version int) (*jsonentity.PathResponse, error) {
+	r := jsonentity.PathResponse{From: q.Start, To: q.End, Paths: make([]jsonentity.PathCost, 0)}
+
+	pa, err := graph.GetTopKPaths(ctx, q.Start, q.End, q.K, makeFilter(q.Filter))
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case len(pa) > 0 && version == jsonentity.VersionTyped:
+		r.Paths = makePaths(graph, pa, version)
+	case len(pa) > 0:
+		paths := make([]jsonentity.PathCost, 0, len(pa))
+		for _, p := range pa {
+			paths = append(paths, jsonentity.PathCost{Path: p.Path, Cost: p.TotalCost})
+		}
+		r.Paths = paths
+	}
+
+	return &r, nil
+}
+
+// makePath returns typed path in VersionTyped and node IDs otherwise
+func makePath(graph *entity.Graph, p entity.PathsCost, version int) interface{} {
+	if version != jsonentity.VersionTyped {
+		return p.Path
+	}
+
+	return jsonentity.Path{
+		Nodes:             p.Path,
+		Edges:             p.Edges,
+		Cost:              p.TotalCost,
+		Hops:              len(p.Edges),
+		ElementAttributes: elementAttributes(graph, p.Path, p.Edges),
+	}
+}
+
+func makePaths(graph *entity.Graph, pa []entity.PathsCost, version int) interface{} {
+	if version != jsonentity.VersionTyped {
+		paths := make([][]string, 0, len(pa))
+		for _, p := range pa {
+			paths = append(paths, p.Path)
+		}
+
+		return paths
+	}
+
+	paths := make([]jsonentity.Path, 0, len(pa))
+	for _, p := range pa {
+		paths = append(paths, makePath(graph, p, version).(jsonentity.Path))
+	}
+
+	return paths
+}
+
+func getCycles(ctx context.Context, graph *entity.Graph, limit, version int) (*jsonentity.CyclesResponse, error) {
+	if limit <= 0 {
+		limit = constant.DefaultCyclesLimit
+	}
+
+	// search one more cycle to know if there are more than limit
+	cycles, err := graph.FindCycles(ctx, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	r := jsonentity.CyclesResponse{Cycles: make([]jsonentity.Cycle, 0, len(cycles)), Truncated: len(cycles) > limit}
+	for _, c := range cycles[:min(len(cycles), limit)] {
+		r.Cycles = append(r.Cycles, makeCycle(graph, c, version))
+	}
+
+	return &r, nil
+}
+
+// makeCycle has attributes of cycle nodes and edges in VersionTyped only, the same as makePath
+func makeCycle(graph *entity.Graph, c entity.Cycle, version int) jsonentity.Cycle {
+	if version != jsonentity.VersionTyped {
+		return jsonentity.Cycle{Nodes: c.Nodes, Edges: c.Edges}
+	}
+
+	return jsonentity.Cycle{
+		Nodes:             c.Nodes,
+		Edges:             c.Edges,
+		ElementAttributes: elementAttributes(graph, c.Nodes, c.Edges),
+	}
+}
+
+func getSCC(ctx context.Context, graph *entity.Graph, q jsonentity.SCCQuery) (*jsonentity.SCCResponse, error) {
+	if !q.Condensation {
+		components, err := graph.StronglyConnectedComponents(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return &jsonentity.SCCResponse{Components: components}, nil
+	}
+
+	cond, err := graph.Condense(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r := jsonentity.SCCResponse{Components: cond.Components, Edges: make([]jsonentity.ComponentEdge, 0)}
+	for from, to := range cond.Edges {
+		for _, t := range to {
+			r.Edges = append(r.Edges, jsonentity.ComponentEdge{From: from, To: t})
+		}
+	}
+
+	return &r, nil
+}
+
+// getReachable answers reachable or ancestors query searched by search
+func getReachable(ctx context.Context, q jsonentity.NodeQuery, search func(context.Context, string, entity.Filter) ([]string, error)) (*jsonentity.NodesResponse, error) {
+	nodes, err := search(ctx, q.Node, makeFilter(q.Filter))
+	if err != nil {
+		return nil, err
+	}
+
+	return &jsonentity.NodesResponse{Node: q.Node, Nodes: nodes}, nil
+}
+
+func getNeighbourhood(ctx context.Context, graph *entity.Graph, q jsonentity.NeighbourhoodQuery) (*jsonentity.NeighbourhoodResponse, error) {
+	reached, err := graph.Neighbourhood(ctx, q.Node, entity.NeighbourhoodLimit{
+		MaxHops: q.MaxHops,
+		MaxCost: q.MaxCost,
+		Reverse: q.Reverse,
+	}, makeFilter(q.Filter))
+	if err != nil {
+		return nil, err
+	}
+
+	r := jsonentity.NeighbourhoodResponse{Node: q.Node, Nodes: make([]jsonentity.Reach, 0, len(reached))}
+	for _, n := range reached {
+		r.Nodes = append(r.Nodes, jsonentity.Reach{Node: n.Node, Hops: n.Hops, Cost: n.Cost})
+	}
+
+	return &r, nil
+}
+
+func getSSSP(ctx context.Context, graph *entity.Graph, q jsonentity.SSSPQuery) (*jsonentity.SSSPResponse, error) {
+	tree, err := graph.ShortestPathTree(ctx, q.Origin, makeFilter(q.Filter))
+	if err != nil {
+		return nil, err
+	}
+
+	r := jsonentity.SSSPResponse{
+		Origin:       q.Origin,
+		Costs:        tree.Costs,
+		Predecessors: make(map[string]jsonentity.Predecessor, len(tree.Predecessors)),
+	}
+	for n, p := range tree.Predecessors {
+		r.Predecessors[n] = jsonentity.Predecessor{Node: p.Node, Edge: p.Edge}
+	}
+
+	return &r, nil
+}
+
+func getMatrix(ctx context.Context, graph *entity.Graph, q jsonentity.MatrixQuery, workers, version int) (*jsonentity.MatrixResponse, error) {
+	destinations := q.Destinations
+	if len(destinations) == 0 {
+		destinations = q.Origins
+	}
+
+	matrix, cycles, err := graph.DistanceMatrix(ctx, q.Origins, destinations, makeFilter(q.Filter), workers)
+	if err != nil {
+		return nil, err
+	}
+
+	r := jsonentity.MatrixResponse{Origins: q.Origins, Destinations: destinations, Costs: make([][]*float64, 0, len(matrix))}
+	for i, row := range matrix {
+		// negative cycle fails only the row of origin it is reached from
+		if cycles[i] != nil {
+			if r.Errors == nil {
+				r.Errors = make([]*jsonentity.QueryError, len(matrix))
 			}
+			r.Errors[i] = searchError(graph, cycles[i], version)
+			r.Costs = append(r.Costs, nil)
+			continue
+		}
 
-			res.Answers = append(res.Answers, map[string]jsonentity.PathResponse{"cheapest": r})
+		costs := make([]*float64, len(row))
+		for j := range row {
+			// unreachable destination is null, JSON has no infinity
+			if !math.IsInf(row[j], 1) {
+				costs[j] = &row[j]
+			}
 		}
+		r.Costs = append(r.Costs, costs)
+	}
+
+	return &r, nil
+}
+
+func getTopo(ctx context.Context, graph *entity.Graph, version int) (*jsonentity.TopoResponse, error) {
+	order, cycle, err := graph.TopologicalSort(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-		if q.Paths != nil && q.Paths.Start != "" && q.Paths.End != "" {
-			r := jsonentity.PathResponse{From: q.Paths.Start, To: q.Paths.End, Paths: make([]string, 0)}
+	if cycle != nil {
+		c := makeCycle(graph, *cycle, version)
+		return &jsonentity.TopoResponse{Cycle: &c}, nil
+	}
+
+	return &jsonentity.TopoResponse{Order: order}, nil
+}
+
+func getCriticalPath(ctx context.Context, graph *entity.Graph, version int) (*jsonentity.CriticalPathResponse, error) {
+	s, cycle, err := graph.CriticalPath(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if cycle != nil {
+		c := makeCycle(graph, *cycle, version)
+		return &jsonentity.CriticalPathResponse{Cycle: &c}, nil
+	}
+
+	r := jsonentity.CriticalPathResponse{
+		Duration: s.Duration,
+		Path:     makePath(graph, s.Critical, version),
+		Nodes:    make([]jsonentity.NodeSchedule, 0, len(s.Order)),
+	}
 
-			pa := graph.GetPaths(q.Paths.Start, q.Paths.End)
-			if len(pa) > 0 {
-				r.Paths = pa
+	for _, n := range s.Order {
+		ns := s.Nodes[n]
+		r.Nodes = append(r.Nodes, jsonentity.NodeSchedule{
+			Node:          n,
+			EarliestStart: ns.EarliestStart,
+			LatestStart:   ns.LatestStart,
+			Slack:         ns.Slack,
+		})
+	}
+
+	return &r, nil
+}
+
+// elementAttributes collects attributes of path nodes and edges, edges[i] goes out of nodes[i]
+func elementAttributes(graph *entity.Graph, nodes, edges []string) jsonentity.ElementAttributes {
+	var a jsonentity.ElementAttributes
+
+	for _, id := range nodes {
+		if n := graph.Nodes[id]; len(n.Attributes) > 0 {
+			if a.NodeAttributes == nil {
+				a.NodeAttributes = make(map[string]map[string]interface{})
 			}
+			a.NodeAttributes[id] = n.Attributes
+		}
+	}
 
-			res.Answers = append(res.Answers, map[string]jsonentity.PathResponse{"paths": r})
+	for i, id := range edges {
+		if e, ok := graph.Edge(nodes[i], id); ok && len(e.Attributes) > 0 {
+			if a.EdgeAttributes == nil {
+				a.EdgeAttributes = make(map[string]map[string]interface{})
+			}
+			a.EdgeAttributes[id] = e.Attributes
 		}
 	}
 
-	return &res
+	return a
 }
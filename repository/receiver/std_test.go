@@ -1,9 +1,18 @@
 package receiver
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"graphs/entity"
 	jsonentity "graphs/entity/json"
+	"graphs/entity/postgre"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 func BenchmarkGetAnswer(b *testing.B) {
@@ -18,6 +27,7 @@ func BenchmarkGetAnswer(b *testing.B) {
 			"i": {{Next: "h", Cost: 10}},
 			"h": {{Next: "g", Cost: 10}},
 			"d": {{Next: "g", Cost: 10}},
+			"g": nil,
 		},
 	}
 	query := jsonentity.RequestQuery{
@@ -35,7 +45,10 @@ func BenchmarkGetAnswer(b *testing.B) {
 	}
 
 	b.ResetTimer()
-	GetAnswer(&graph, &query)
+	for i := 0; i < b.N; i++ {
+		answer := GetAnswer(context.Background(), &graph, &query, Config{})
+		assertNoErrors(b, answer)
+	}
 }
 
 func BenchmarkGetAnswerIterate(b *testing.B) {
@@ -49,6 +62,7 @@ func BenchmarkGetAnswerIterate(b *testing.B) {
 			"i": {{Next: "h", Cost: 10}},
 			"h": {{Next: "g", Cost: 10}},
 			"d": {{Next: "g", Cost: 10}},
+			"g": nil,
 		},
 	}
 	query := jsonentity.RequestQuery{
@@ -66,5 +80,498 @@ func BenchmarkGetAnswerIterate(b *testing.B) {
 	}
 
 	b.ResetTimer()
-	GetAnswerIterate(&graph, &query)
+	for i := 0; i < b.N; i++ {
+		answer := GetAnswerIterate(context.Background(), &graph, &query, Config{})
+		assertNoErrors(b, answer)
+	}
+}
+
+// assertNoErrors fails if some query is answered with error, so benchmarks don't time the validation only
+func assertNoErrors(tb testing.TB, answer *jsonentity.Answer) {
+	for i, a := range answer.Answers {
+		if a.Error != nil {
+			tb.Fatalf("query %d: unexpected error %+v", i, a.Error)
+		}
+	}
+}
+
+func testGraph() *entity.Graph {
+	return &entity.Graph{
+		AdjacencyList: map[string][]entity.Edge{
+			"a": {{ID: "a1", Next: "e", Cost: 42}, {ID: "a2", Next: "b", Cost: 10}},
+			"e": {{ID: "e1", Next: "c", Cost: 3}},
+			"c": {{ID: "c1", Next: "a", Cost: 42}, {ID: "c2", Next: "d", Cost: 5}},
+			"b": {{ID: "b1", Next: "d", Cost: 20}, {ID: "b2", Next: "f", Cost: 10}},
+			"f": {{ID: "f1", Next: "i", Cost: 10}},
+			"i": {{ID: "i1", Next: "h", Cost: 10}},
+			"h": {{ID: "h1", Next: "g", Cost: 10}},
+			"d": {{ID: "d1", Next: "g", Cost: 10}},
+			"g": nil,
+		},
+	}
+}
+
+func TestGetAnswerMatchesIterate(t *testing.T) {
+	graph := testGraph()
+	query := jsonentity.RequestQuery{
+		Queries: []jsonentity.Query{
+			{ID: "q1", Paths: &jsonentity.PathQuery{Start: "a", End: "e"}},
+			{ID: "q2", Cheapest: &jsonentity.PathQuery{Start: "a", End: "g"}},
+			{Paths: &jsonentity.PathQuery{Start: "a", End: "g"}},
+			{ID: "q4", Cheapest: &jsonentity.PathQuery{Start: "g", End: "a"}},
+			{ID: "q5", TopK: &jsonentity.TopKQuery{Start: "a", End: "g", K: 3}},
+			{ID: "q6", Cycles: &jsonentity.CyclesQuery{}},
+			{ID: "q7", Cheapest: &jsonentity.PathQuery{Start: "a", End: "z"}},
+			{ID: "q8"},
+			{Paths: &jsonentity.PathQuery{Start: "b", End: "g"}, Cheapest: &jsonentity.PathQuery{Start: "b", End: "g"}},
+			{ID: "q10", Matrix: &jsonentity.MatrixQuery{Origins: []string{"a", "b", "c", "d", "e", "f"}, Destinations: []string{"g", "a"}}},
+		},
+	}
+
+	expected := GetAnswerIterate(context.Background(), graph, &query, Config{})
+	if len(expected.Answers) != len(query.Queries) {
+		t.Fatalf("expected %d answers, got %d", len(query.Queries), len(expected.Answers))
+	}
+
+	for i, q := range query.Queries {
+		if expected.Answers[i].ID != q.ID {
+			t.Errorf("answer %d: expected id %q, got %q", i, q.ID, expected.Answers[i].ID)
+		}
+	}
+
+	// concurrent evaluation must not depend on goroutines scheduling
+	for run := 0; run < 50; run++ {
+		if got := GetAnswer(context.Background(), graph, &query, Config{Workers: 3}); !reflect.DeepEqual(expected, got) {
+			t.Fatalf("run %d: GetAnswer differs from GetAnswerIterate\nexpected: %+v\ngot: %+v", run, expected, got)
+		}
+	}
+}
+
+func TestGetAnswerTyped(t *testing.T) {
+	graph := testGraph()
+
+	var (
+		nodes = []string{"a", "b", "c", "d", "e", "f", "g", "h", "i"}
+		query = jsonentity.RequestQuery{Version: jsonentity.VersionTyped}
+	)
+	for _, start := range nodes {
+		for _, end := range nodes {
+			query.Queries = append(query.Queries,
+				jsonentity.Query{Cheapest: &jsonentity.PathQuery{Start: start, End: end}},
+				jsonentity.Query{Paths: &jsonentity.PathQuery{Start: start, End: end}})
+		}
+	}
+
+	data, err := json.Marshal(GetAnswer(context.Background(), graph, &query, Config{}))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	// answer as decoded by a client of the typed protocol
+	var answer struct {
+		Version int `json:"version"`
+		Answers []struct {
+			Cheapest *struct {
+				Path json.RawMessage `json:"path"`
+			} `json:"cheapest"`
+			Paths *struct {
+				Paths []jsonentity.Path `json:"paths"`
+			} `json:"paths"`
+			Error *jsonentity.QueryError `json:"error"`
+		} `json:"answers"`
+	}
+	if err := json.Unmarshal(data, &answer); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if answer.Version != jsonentity.VersionTyped || len(answer.Answers) != len(query.Queries) {
+		t.Fatalf("expected %d answers of version %d, got %s", len(query.Queries), jsonentity.VersionTyped, data)
+	}
+
+	for i := 0; i < len(query.Queries); i += 2 {
+		start, end := query.Queries[i].Cheapest.Start, query.Queries[i].Cheapest.End
+		cheapest, paths := answer.Answers[i], answer.Answers[i+1]
+		if cheapest.Error != nil || cheapest.Cheapest == nil || paths.Error != nil || paths.Paths == nil {
+			t.Fatalf("%s -> %s: expected answers, got %+v %+v", start, end, cheapest, paths)
+		}
+
+		expected, err := graph.GetCheapestPaths(context.Background(), start, end, entity.Filter{})
+		if err != nil {
+			t.Fatalf("%s -> %s: unexpected error %v", start, end, err)
+		}
+
+		if expected == nil {
+			if string(cheapest.Cheapest.Path) != "false" {
+				t.Errorf("%s -> %s: expected false path, got %s", start, end, cheapest.Cheapest.Path)
+			}
+			if len(paths.Paths.Paths) != 0 {
+				t.Errorf("%s -> %s: expected no paths, got %+v", start, end, paths.Paths.Paths)
+			}
+			continue
+		}
+
+		var path jsonentity.Path
+		if err := json.Unmarshal(cheapest.Cheapest.Path, &path); err != nil {
+			t.Fatalf("%s -> %s: expected typed path, got %s", start, end, cheapest.Cheapest.Path)
+		}
+
+		if !reflect.DeepEqual(expected.Path, path.Nodes) || !reflect.DeepEqual(expected.Edges, path.Edges) ||
+			expected.TotalCost != path.Cost || len(expected.Edges) != path.Hops {
+			t.Errorf("%s -> %s: expected %+v, got %+v", start, end, expected, path)
+		}
+
+		for _, p := range append(paths.Paths.Paths, path) {
+			assertTypedPath(t, graph, p)
+		}
+	}
+}
+
+// assertTypedPath checks edges of path lead from node to node and add up to its cost and hops
+func assertTypedPath(t *testing.T, graph *entity.Graph, path jsonentity.Path) {
+	t.Helper()
+
+	if len(path.Nodes) != len(path.Edges)+1 || path.Hops != len(path.Edges) {
+		t.Errorf("path %+v: expected %d hops and %d nodes", path, len(path.Edges), len(path.Edges)+1)
+		return
+	}
+
+	var cost float64
+	for i, id := range path.Edges {
+		e, ok := graph.Edge(path.Nodes[i], id)
+		if !ok || e.Next != path.Nodes[i+1] {
+			t.Errorf("path %+v: edge %s does not lead from %s to %s", path, id, path.Nodes[i], path.Nodes[i+1])
+			return
+		}
+		cost += e.Cost
+	}
+
+	if cost != path.Cost {
+		t.Errorf("path %+v: expected cost %v, got %v", path, cost, path.Cost)
+	}
+}
+
+func TestGetAnswerLegacyGolden(t *testing.T) {
+	query := jsonentity.RequestQuery{
+		Queries: []jsonentity.Query{
+			{ID: "cheapest", Cheapest: &jsonentity.PathQuery{Start: "a", End: "g"}},
+			{ID: "no route", Cheapest: &jsonentity.PathQuery{Start: "g", End: "a"}},
+			{ID: "paths", Paths: &jsonentity.PathQuery{Start: "b", End: "g"}},
+			{ID: "top_k", TopK: &jsonentity.TopKQuery{Start: "a", End: "g", K: 2}},
+			{ID: "cycles", Cycles: &jsonentity.CyclesQuery{}},
+		},
+	}
+	golden := `{"answers":[` +
+		`{"id":"cheapest","cheapest":{"from":"a","to":"g","path":["a","b","d","g"]}},` +
+		`{"id":"no route","cheapest":{"from":"g","to":"a","path":false}},` +
+		`{"id":"paths","paths":{"from":"b","to":"g","paths":[["b","d","g"],["b","f","i","h","g"]]}},` +
+		`{"id":"top_k","top_k":{"from":"a","to":"g","paths":[{"path":["a","b","d","g"],"cost":40},{"path":["a","b","f","i","h","g"],"cost":50}]}},` +
+		`{"id":"cycles","cycles":{"cycles":[{"nodes":["a","e","c","a"],"edges":["a1","e1","c1"]}],"truncated":false}}]}`
+
+	got, err := json.Marshal(GetAnswer(context.Background(), testGraph(), &query, Config{}))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if string(got) != golden {
+		t.Errorf("expected %s\ngot %s", golden, got)
+	}
 }
+
+func TestGetAnswerErrors(t *testing.T) {
+	query := jsonentity.RequestQuery{
+		Queries: []jsonentity.Query{
+			{ID: "unknown", Cheapest: &jsonentity.PathQuery{Start: "a", End: "z"}},
+			{ID: "empty"},
+			{ID: "invalid", TopK: &jsonentity.TopKQuery{Start: "a", End: "g"}},
+			{ID: "no route", Cheapest: &jsonentity.PathQuery{Start: "g", End: "a"}},
+		},
+	}
+
+	answer := GetAnswer(context.Background(), testGraph(), &query, Config{})
+
+	for i, code := range []string{jsonentity.ErrCodeUnknownNode, jsonentity.ErrCodeEmptyQuery, jsonentity.ErrCodeInvalidQuery} {
+		if err := answer.Answers[i].Error; err == nil || err.Code != code {
+			t.Errorf("answer %s: expected error %s, got %+v", answer.Answers[i].ID, code, err)
+		}
+	}
+
+	if noRoute := answer.Answers[3]; noRoute.Error != nil || noRoute.Cheapest == nil || noRoute.Cheapest.Path != false {
+		t.Errorf("answer %s: expected no route without error, got %+v", noRoute.ID, noRoute)
+	}
+}
+
+func TestValidateFilter(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter *jsonentity.Filter
+		valid  bool
+	}{
+		{name: "no filter", valid: true},
+		{name: "ids", filter: &jsonentity.Filter{ExcludeNodes: []jsonentity.Predicate{{IDs: []string{"d"}}}}, valid: true},
+		{name: "attribute", filter: &jsonentity.Filter{IncludeEdges: []jsonentity.Predicate{{Attribute: "lanes", Op: "gte", Value: 2.0}}}, valid: true},
+		{name: "exists without value", filter: &jsonentity.Filter{IncludeNodes: []jsonentity.Predicate{{Attribute: "toll", Op: "exists"}}}, valid: true},
+		{name: "empty predicate", filter: &jsonentity.Filter{ExcludeEdges: []jsonentity.Predicate{{}}}},
+		{name: "unknown op", filter: &jsonentity.Filter{IncludeNodes: []jsonentity.Predicate{{Attribute: "kind", Op: "like", Value: "road"}}}},
+		{name: "missing value", filter: &jsonentity.Filter{IncludeNodes: []jsonentity.Predicate{{Attribute: "kind", Op: "ne"}}}},
+		{name: "string value with lt", filter: &jsonentity.Filter{IncludeNodes: []jsonentity.Predicate{{Attribute: "kind", Op: "lt", Value: "road"}}}},
+		{name: "object value", filter: &jsonentity.Filter{ExcludeNodes: []jsonentity.Predicate{{Attribute: "kind", Value: map[string]interface{}{}}}}},
+	}
+
+	for _, tt := range tests {
+		err := validateFilter("cheapest", tt.filter)
+		switch {
+		case tt.valid && err != nil:
+			t.Errorf("%s: expected valid filter, got %+v", tt.name, err)
+		case !tt.valid && (err == nil || err.Code != jsonentity.ErrCodeInvalidQuery):
+			t.Errorf("%s: expected %s error, got %+v", tt.name, jsonentity.ErrCodeInvalidQuery, err)
+		}
+	}
+}
+
+func TestGetAnswerFilter(t *testing.T) {
+	// d is on the cheapest path a b d g, and on a e c d g
+	exclude := &jsonentity.Filter{ExcludeNodes: []jsonentity.Predicate{{IDs: []string{"d"}}}}
+	query := jsonentity.RequestQuery{
+		Queries: []jsonentity.Query{
+			{ID: "cheapest", Cheapest: &jsonentity.PathQuery{Start: "a", End: "g", Filter: exclude}},
+			{ID: "paths", Paths: &jsonentity.PathQuery{Start: "a", End: "g", Filter: exclude}},
+			{ID: "top_k", TopK: &jsonentity.TopKQuery{Start: "a", End: "g", K: 3, Filter: exclude}},
+			{ID: "unfiltered", Cheapest: &jsonentity.PathQuery{Start: "a", End: "g"}},
+		},
+	}
+
+	answer := GetAnswer(context.Background(), testGraph(), &query, Config{})
+	assertNoErrors(t, answer)
+
+	for _, tt := range []struct {
+		got      interface{}
+		expected string
+	}{
+		{got: answer.Answers[0].Cheapest.Path, expected: `["a","b","f","i","h","g"]`},
+		{got: answer.Answers[1].Paths.Paths, expected: `[["a","b","f","i","h","g"]]`},
+		{got: answer.Answers[2].TopK.Paths, expected: `[{"path":["a","b","f","i","h","g"],"cost":50}]`},
+		{got: answer.Answers[3].Cheapest.Path, expected: `["a","b","d","g"]`},
+	} {
+		if got, err := json.Marshal(tt.got); err != nil || string(got) != tt.expected {
+			t.Errorf("expected %s, got %s", tt.expected, got)
+		}
+	}
+}
+
+func TestGetAnswerMatrixNegativeCycle(t *testing.T) {
+	// negative cycle c d is reached from a only
+	graph := entity.Graph{
+		AdjacencyList: map[string][]entity.Edge{
+			"a": {{ID: "a1", Next: "c", Cost: 1}},
+			"b": {{ID: "b1", Next: "x", Cost: 2}},
+			"c": {{ID: "c1", Next: "d", Cost: -3}},
+			"d": {{ID: "d1", Next: "c", Cost: 1}},
+			"x": nil,
+		},
+		NegativeCosts: true,
+	}
+	query := jsonentity.RequestQuery{
+		Queries: []jsonentity.Query{{Matrix: &jsonentity.MatrixQuery{Origins: []string{"a", "b"}, Destinations: []string{"x", "b"}}}},
+	}
+
+	answer := GetAnswer(context.Background(), &graph, &query, Config{})
+	assertNoErrors(t, answer)
+
+	got, err := json.Marshal(answer.Answers[0].Matrix)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	expected := `{"origins":["a","b"],"destinations":["x","b"],"costs":[null,[2,0]],"errors":[` +
+		`{"code":"negative_cycle","message":"negative cost cycle c -\u003e d -\u003e c","cycle":{"nodes":["c","d","c"],"edges":["c1","d1"]}},null]}`
+	if string(got) != expected {
+		t.Errorf("expected %s\ngot %s", expected, got)
+	}
+}
+
+func TestGetAnswerBidirectionalEdge(t *testing.T) {
+	graph := entity.NewGraph(postgre.Graph{
+		Nodes: []postgre.Node{{ID: "a"}, {ID: "b"}},
+		Edges: []postgre.Edge{{ID: "a1", PreviousNode: "a", NextNode: "b", Cost: 1, Bidirectional: true}},
+	})
+	query := jsonentity.RequestQuery{
+		Queries: []jsonentity.Query{{Topo: &jsonentity.TopoQuery{}}, {CriticalPath: &jsonentity.CriticalPathQuery{}}},
+	}
+
+	answer := GetAnswer(context.Background(), graph, &query, Config{})
+
+	for i, a := range answer.Answers {
+		if a.Error == nil || a.Error.Code != jsonentity.ErrCodeBidirectionalEdge {
+			t.Errorf("answer %d: expected %s error, got %+v", i, jsonentity.ErrCodeBidirectionalEdge, a)
+		}
+	}
+}
+
+func TestGetAnswerAttributesByVersion(t *testing.T) {
+	graph := entity.NewGraph(postgre.Graph{
+		Nodes: []postgre.Node{{ID: "a", Attributes: postgre.Attributes{"capacity": 10.0}}, {ID: "b"}, {ID: "c"}},
+		Edges: []postgre.Edge{
+			{ID: "a1", PreviousNode: "a", NextNode: "b", Cost: 1, Attributes: postgre.Attributes{"mode": "rail"}},
+			{ID: "b1", PreviousNode: "b", NextNode: "a", Cost: -2},
+			{ID: "b2", PreviousNode: "b", NextNode: "c", Cost: 1},
+		},
+	})
+	queries := []jsonentity.Query{
+		{Cheapest: &jsonentity.PathQuery{Start: "a", End: "c"}},
+		{Cycles: &jsonentity.CyclesQuery{}},
+		{Topo: &jsonentity.TopoQuery{}},
+	}
+
+	for _, tt := range []struct {
+		version  int
+		expected string
+	}{
+		{
+			version: jsonentity.VersionLegacy,
+			expected: `{"version":1,"answers":[` +
+				`{"error":{"code":"negative_cycle","message":"negative cost cycle a -\u003e b -\u003e a","cycle":{"nodes":["a","b","a"],"edges":["a1","b1"]}}},` +
+				`{"cycles":{"cycles":[{"nodes":["a","b","a"],"edges":["a1","b1"]}],"truncated":false}},` +
+				`{"topo":{"cycle":{"nodes":["a","b","a"],"edges":["a1","b1"]}}}]}`,
+		},
+		{
+			version: jsonentity.VersionTyped,
+			expected: `{"version":2,"answers":[` +
+				`{"error":{"code":"negative_cycle","message":"negative cost cycle a -\u003e b -\u003e a","cycle":{"nodes":["a","b","a"],"edges":["a1","b1"],` +
+				`"node_attributes":{"a":{"capacity":10}},"edge_attributes":{"a1":{"mode":"rail"}}}}},` +
+				`{"cycles":{"cycles":[{"nodes":["a","b","a"],"edges":["a1","b1"],` +
+				`"node_attributes":{"a":{"capacity":10}},"edge_attributes":{"a1":{"mode":"rail"}}}],"truncated":false}},` +
+				`{"topo":{"cycle":{"nodes":["a","b","a"],"edges":["a1","b1"],` +
+				`"node_attributes":{"a":{"capacity":10}},"edge_attributes":{"a1":{"mode":"rail"}}}}}]}`,
+		},
+	} {
+		query := jsonentity.RequestQuery{Version: tt.version, Queries: queries}
+
+		got, err := json.Marshal(GetAnswer(context.Background(), graph, &query, Config{}))
+		if err != nil {
+			t.Fatalf("version %d: unexpected error %v", tt.version, err)
+		}
+
+		if string(got) != tt.expected {
+			t.Errorf("version %d: expected %s\ngot %s", tt.version, tt.expected, got)
+		}
+	}
+}
+
+func TestGetAnswerTimeout(t *testing.T) {
+	// complete graph has too many simple paths to enumerate them in time
+	graph := entity.Graph{AdjacencyList: make(map[string][]entity.Edge)}
+	for i := 0; i < 20; i++ {
+		for j := 0; j < 20; j++ {
+			if i != j {
+				graph.AdjacencyList[strconv.Itoa(i)] = append(graph.AdjacencyList[strconv.Itoa(i)], entity.Edge{Next: strconv.Itoa(j), Cost: 1})
+			}
+		}
+	}
+
+	query := jsonentity.RequestQuery{
+		Queries: []jsonentity.Query{
+			{ID: "slow", Paths: &jsonentity.PathQuery{Start: "0", End: "19"}},
+			{ID: "fast", Cheapest: &jsonentity.PathQuery{Start: "0", End: "19"}},
+		},
+	}
+
+	answer := GetAnswer(context.Background(), &graph, &query, Config{Workers: 1, QueryTimeout: 50 * time.Millisecond})
+
+	if err := answer.Answers[0].Error; err == nil || err.Code != jsonentity.ErrCodeTimeout {
+		t.Errorf("expected timeout error, got %+v", answer.Answers[0])
+	}
+
+	if fast := answer.Answers[1]; fast.Error != nil || fast.Cheapest == nil {
+		t.Errorf("expected cheapest path, got %+v", fast)
+	}
+}
+
+func TestReceive(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		code  string // error code, empty if request is answered
+	}{
+		{name: "answer", input: `{"queries":[{"cheapest":{"start":"a","end":"b"}}]}`},
+		{name: "invalid JSON", input: `{"queries":`, code: jsonentity.ErrCodeInvalidRequest},
+		{name: "unsupported version", input: `{"version":7,"queries":[]}`, code: jsonentity.ErrCodeInvalidRequest},
+		{name: "unknown graph", input: `{"graph":"g1","queries":[]}`, code: jsonentity.ErrCodeUnknownGraph},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				receive(context.Background(), strings.NewReader(tt.input), &out, testGraphs(), Config{})
+			}()
+
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("expected receive to return on EOF")
+			}
+
+			output := strings.TrimSuffix(strings.TrimPrefix(out.String(), "> "), "> \n")
+			var answer struct {
+				Error   *jsonentity.QueryError `json:"error"`
+				Answers []jsonentity.QueryAnswer
+			}
+			if err := json.Unmarshal([]byte(output), &answer); err != nil {
+				t.Fatalf("expected JSON answer, got %q: %v", out.String(), err)
+			}
+
+			switch {
+			case tt.code == "" && (answer.Error != nil || len(answer.Answers) != 1):
+				t.Errorf("expected answer, got %q", output)
+			case tt.code != "" && (answer.Error == nil || answer.Error.Code != tt.code):
+				t.Errorf("expected error %s, got %q", tt.code, output)
+			}
+		})
+	}
+}
+
+// makeBenchGraph builds a deterministic directed graph with nodes*degree edges.
+// Every node links to the next one so the last node is always reachable from the first.
+func makeBenchGraph(nodes, degree int) *entity.Graph {
+	var (
+		graph = entity.Graph{AdjacencyList: make(map[string][]entity.Edge, nodes)}
+		rnd   = rand.New(rand.NewSource(42))
+	)
+
+	for i := 0; i < nodes; i++ {
+		edges := make([]entity.Edge, 0, degree)
+		if i+1 < nodes {
+			edges = append(edges, entity.Edge{Next: strconv.Itoa(i + 1), Cost: 100})
+		}
+
+		for len(edges) < degree {
+			edges = append(edges, entity.Edge{Next: strconv.Itoa(rnd.Intn(nodes)), Cost: float64(rnd.Intn(100) + 1)})
+		}
+
+		graph.AdjacencyList[strconv.Itoa(i)] = edges
+	}
+
+	return &graph
+}
+
+func benchmarkGetCheapestPaths(b *testing.B, nodes, degree int) {
+	graph := makeBenchGraph(nodes, degree)
+	end := strconv.Itoa(nodes - 1)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if path, _ := graph.GetCheapestPaths(context.Background(), "0", end, entity.Filter{}); path == nil {
+			b.Fatalf("path 0 -> %s not found", end)
+		}
+	}
+}
+
+func BenchmarkGetCheapestPaths1kEdges(b *testing.B) { benchmarkGetCheapestPaths(b, 100, 10) }
+
+func BenchmarkGetCheapestPaths10kEdges(b *testing.B) { benchmarkGetCheapestPaths(b, 1000, 10) }
+
+func BenchmarkGetCheapestPaths100kEdges(b *testing.B) { benchmarkGetCheapestPaths(b, 10000, 10) }
+
+func BenchmarkGetCheapestPaths1MEdges(b *testing.B) { benchmarkGetCheapestPaths(b, 100000, 10) }
@@ -9,6 +9,9 @@ export DB_NAME=graph
 export DB_SCHEMA=graph
 export SSL_MODE=false
 
+#Receivers, stdin is off by default with HTTP server
+export HTTP_ADDR=:8080
+
 go build -o graphs .
 
 if [ $? -ne 0 ]; then